@@ -125,7 +125,7 @@ func writeOutput(outFile *os.File) error {
 // To change a pattern, edit the correct file under patterns and run
 // "make generate" from the project root
 
-package filters
+package grok
 
 var grokPatterns = map[string]string{
 `)
@@ -1,16 +1,22 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
+	"github.com/lfkeitel/spartan/config"
+	"github.com/lfkeitel/spartan/config/parser"
 	"github.com/lfkeitel/spartan/event"
 	"github.com/lfkeitel/spartan/filters"
+	"github.com/lfkeitel/spartan/grok"
 	"github.com/lfkeitel/spartan/inputs"
 	"github.com/lfkeitel/spartan/outputs"
+	"github.com/lfkeitel/spartan/utils"
 )
 
 var (
@@ -27,9 +33,9 @@ var (
 
 func init() {
 	flag.StringVar(&configFile, "c", "", "Configuration file path")
-	flag.StringVar(&filtersPath, "f", "", "Filter path, can be a file or directory")
+	flag.StringVar(&filtersPath, "f", "", "Filter path, can be a file or directory of .conf files")
 	flag.BoolVar(&verFlag, "v", false, "Display version information")
-	flag.BoolVar(&testConfig, "t", false, "Test main configuration")
+	flag.BoolVar(&testConfig, "t", false, "Test configuration and exit")
 }
 
 func main() {
@@ -45,91 +51,151 @@ func main() {
 		return
 	}
 
-	grokOptions := map[string]interface{}{
-		"regex": `^(?P<logdate>%{MONTHDAY}[-]%{MONTH}[-]%{YEAR} %{TIME}) client %{IP:clientip}#%{POSINT:clientport} \(%{GREEDYDATA:query}\): query: %{GREEDYDATA:target} IN %{GREEDYDATA:querytype} \(%{IP:dns}\)$`,
-	}
-	dateOptions := map[string]interface{}{
-		"field":    "logdate",
-		"patterns": "02-Jan-2006 15:04:05.999999999",
-		"timezone": "America/Chicago",
+	mainConfig, err := loadMainConfig(configFile)
+	if err != nil {
+		fmt.Printf("Error loading main configuration:\n%v\n", err)
+		os.Exit(1)
 	}
-	mutateOptions := map[string]interface{}{
-		"action": "remove_field",
-		"fields": []string{"logdate", "message"},
+
+	utils.Log.SetLevel(mainConfig.Logging.Level)
+
+	if err := loadPatterns(mainConfig); err != nil {
+		fmt.Printf("Error loading grok patterns: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Inputs
-	file, err := inputs.New("file", map[string]interface{}{
-		"path": os.Args[1],
-	})
-	if err != nil {
-		fmt.Println(err)
+	if filtersPath == "" {
+		fmt.Println("No filter configuration given, use -f")
 		os.Exit(1)
 	}
 
-	// Filters
-	mutateFilter, err := filters.New("mutate", mutateOptions)
+	pipelineConfig, err := loadPipelineConfig(filtersPath)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Printf("Error loading filter configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	dateFilter, err := filters.New("date", dateOptions)
-	if err != nil {
-		fmt.Println(err)
+	if len(pipelineConfig.Inputs) == 0 {
+		fmt.Println("No inputs defined in filter configuration")
 		os.Exit(1)
 	}
 
-	grok, err := filters.New("grok", grokOptions)
+	// Inputs
+	inputs.SetDataDir(mainConfig.Paths.Data)
+	allInputs, err := inputs.CreateFromDefs(pipelineConfig.Inputs)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	endFilter, _ := filters.New("end", nil)
+	if errs := filters.CheckWorkers(pipelineConfig.Filters, mainConfig.Pipeline.FilterWorkers); len(errs) > 0 {
+		fmt.Println(errs[0])
+		os.Exit(1)
+	}
 
-	filter := filters.NewFilterController(grok, 10)
-	grok.SetNext(dateFilter)
-	dateFilter.SetNext(mutateFilter)
-	mutateFilter.SetNext(endFilter)
+	// Filters, each worker gets its own instance of the filter pipeline
+	filterPipelines := make([]filters.Filter, mainConfig.Pipeline.FilterWorkers)
+	for i := range filterPipelines {
+		filterPipelines[i], err = filters.GeneratePipeline(pipelineConfig.Filters)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	filter := filters.NewFilterController(
+		filterPipelines,
+		mainConfig.Pipeline.FilterBatchSize,
+		mainConfig.Pipeline.FlushInterval,
+		mainConfig.Pipeline.PreserveOrder,
+	)
 
 	// Outputs
-	stdout, _ := outputs.New("stdout", nil)
-	endOutput, _ := outputs.New("end", nil)
-	stdout.SetNext(endOutput)
-	output := outputs.NewOutputController(stdout, 10)
+	outputPipeline, err := outputs.GeneratePipeline(pipelineConfig.Outputs)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	output := outputs.NewOutputController(
+		outputPipeline,
+		mainConfig.Pipeline.OutputBatchSize,
+		mainConfig.Pipeline.FlushInterval,
+	)
 
 	// Communication channels
 	inputChan := make(chan *event.Event)
 	outputChan := make(chan *event.Event)
 
 	// Start everything
-	fmt.Println("Starting outputs")
+	utils.Log.Infof("Starting outputs")
 	output.Start(outputChan)
 
-	fmt.Println("Starting filters")
+	utils.Log.Infof("Starting filters")
 	filter.Start(inputChan, outputChan)
 
-	fmt.Println("Starting inputs")
-	file.Start(inputChan)
+	utils.Log.Infof("Starting inputs")
+	for _, input := range allInputs {
+		if err := input.Start(inputChan); err != nil {
+			fmt.Printf("Error starting input: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	// Wait for Ctrl+C
-	fmt.Println("Waiting for signal")
+	// Wait for Ctrl+C or all inputs to finish
+	utils.Log.Infof("Waiting for signal")
 	shutdownChan := make(chan os.Signal, 1)
 	signal.Notify(shutdownChan, os.Interrupt, syscall.SIGTERM)
-	<-shutdownChan
+	select {
+	case <-shutdownChan:
+	case <-inputs.Finished(allInputs):
+		utils.Log.Infof("All inputs finished")
+	}
 
 	//Shutdown
-	fmt.Println("Shutting down inputs")
-	file.Close()
+	utils.Log.Infof("Shutting down inputs")
+	for _, input := range allInputs {
+		input.Close()
+	}
 
-	fmt.Println("Shutting down filters")
+	utils.Log.Infof("Shutting down filters")
 	filter.Close()
 
-	fmt.Println("Shutting down outputs")
+	utils.Log.Infof("Shutting down outputs")
 	output.Close()
 }
 
+// loadMainConfig loads the main configuration file at path. If path is empty,
+// the default configuration is used.
+func loadMainConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return config.Default(), nil
+	}
+	return config.Load(path)
+}
+
+// loadPatterns loads the extra grok pattern paths from the main configuration.
+func loadPatterns(c *config.Config) error {
+	for _, path := range c.Paths.Patterns {
+		if err := grok.LoadPatterns(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPipelineConfig parses the filter configuration at path. If path is
+// a directory, all .conf files in the directory are parsed in lexicographical order.
+func loadPipelineConfig(path string) (*parser.ParsedFile, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.IsDir() {
+		return parser.ParseGlob(filepath.Join(path, "*.conf"))
+	}
+	return parser.ParseFile(path)
+}
+
 func displayVersionInfo() {
 	fmt.Printf(`Spartan - (C) 2017 Lee Keitel <lee@onesimussystems.com>
 Version:     %s
@@ -140,10 +206,92 @@ Go version:  %s
 }
 
 func testMainConfig() {
-	// _, err := utils.NewConfig(configFile)
-	// if err != nil {
-	// 	fmt.Printf("Error loading configuration: %v\n", err)
-	// 	os.Exit(1)
-	// }
+	var errs []error
+
+	mainConfig, err := loadMainConfig(configFile)
+	if err != nil {
+		if list, ok := err.(config.ErrorList); ok {
+			errs = append(errs, list...)
+		} else {
+			errs = append(errs, err)
+		}
+		mainConfig = config.Default()
+	} else {
+		errs = append(errs, mainConfig.Validate()...)
+	}
+
+	// Patterns are needed to check the grok filters. Missing pattern
+	// paths were already reported.
+	if !hasPatternsError(errs) {
+		if err := loadPatterns(mainConfig); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if filtersPath == "" {
+		errs = append(errs, errors.New("No filter configuration given, use -f"))
+	} else if pipelineConfig, err := loadPipelineConfig(filtersPath); err != nil {
+		errs = append(errs, err)
+	} else {
+		errs = append(errs, checkPipelineConfig(pipelineConfig, mainConfig.Pipeline.FilterWorkers)...)
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		fmt.Printf("Configuration has %d error(s)\n", len(errs))
+		os.Exit(1)
+	}
+
 	fmt.Println("Configuration looks good")
 }
+
+// hasPatternsError returns true if errs has an error for the pattern paths.
+func hasPatternsError(errs []error) bool {
+	for _, err := range errs {
+		if pe, ok := err.(*config.PathError); ok && pe.Setting == "paths.patterns" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPipelineConfig creates every module in the configuration and returns
+// all errors encountered. Unlike building a pipeline, it doesn't stop on the
+// first error. Modules are created but never started. Filters are checked
+// to work with workers filter workers.
+func checkPipelineConfig(pipelineConfig *parser.ParsedFile, workers int) []error {
+	var errs []error
+
+	if len(pipelineConfig.Inputs) == 0 {
+		errs = append(errs, errors.New("No inputs defined in filter configuration"))
+	}
+
+	for _, def := range pipelineConfig.Inputs {
+		if _, err := inputs.New(def.Module, def.Options.Map()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: input %s: %v", def.Position(), def.Module, err))
+		}
+	}
+
+	for _, def := range pipelineConfig.Filters {
+		if def.Condition != nil {
+			continue
+		}
+		if _, err := filters.New(def.Module, def.Options.Map()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: filter %s: %v", def.Position(), def.Module, err))
+		}
+	}
+	errs = append(errs, filters.CheckWorkers(pipelineConfig.Filters, workers)...)
+
+	for _, def := range pipelineConfig.Outputs {
+		if def.Condition != nil {
+			continue
+		}
+		if _, err := outputs.New(def.Module, def.Options.Map()); err != nil {
+			errs = append(errs, fmt.Errorf("%s: output %s: %v", def.Position(), def.Module, err))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,193 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// maxBinaryDepth is how deeply arrays and maps may be nested in data decoded
+// by the binary codecs.
+const maxBinaryDepth = 100
+
+var (
+	errBinaryTruncated = errors.New("Data is truncated")
+	errBinaryTooDeep   = errors.New("Data is nested too deeply")
+)
+
+// A binaryWriter appends values in a binary format. encodeValue walks an Event's
+// values and calls the writer for each one.
+type binaryWriter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeTime(t time.Time)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+}
+
+// IsBinary returns true if c encodes Events as binary data. Binary data may
+// contain any byte so outputs don't add newlines between Events, the binary
+// codecs are Framed instead.
+func IsBinary(c Codec) bool {
+	_, ok := c.(Framed)
+	return ok
+}
+
+// checkBinaryOptions checks options doesn't have options of text codecs. Converting
+// the character set of binary data would corrupt it.
+func checkBinaryOptions(options map[string]interface{}) error {
+	if _, exists := options["charset"]; exists {
+		return errors.New("charset can't be used with binary codecs")
+	}
+	return nil
+}
+
+// encodeEvent writes all fields of e as a map with sorted keys.
+func encodeEvent(w binaryWriter, e *event.Event) {
+	e.ReadFields(func(keys []string, get func(string) interface{}) {
+		w.writeMapHeader(len(keys))
+		for _, key := range keys {
+			w.writeString(key)
+			encodeValue(w, get(key))
+		}
+	})
+}
+
+// encodeValue writes v with w. Slices and maps of any type are written as
+// arrays and maps, maps with sorted keys. Unknown types are written as
+// their text form.
+func encodeValue(w binaryWriter, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		w.writeNil()
+	case bool:
+		w.writeBool(v)
+	case int:
+		w.writeInt(int64(v))
+	case int8:
+		w.writeInt(int64(v))
+	case int16:
+		w.writeInt(int64(v))
+	case int32:
+		w.writeInt(int64(v))
+	case int64:
+		w.writeInt(v)
+	case uint:
+		w.writeUint(uint64(v))
+	case uint8:
+		w.writeUint(uint64(v))
+	case uint16:
+		w.writeUint(uint64(v))
+	case uint32:
+		w.writeUint(uint64(v))
+	case uint64:
+		w.writeUint(v)
+	case float32:
+		w.writeFloat(float64(v))
+	case float64:
+		w.writeFloat(v)
+	case string:
+		w.writeString(v)
+	case []byte:
+		w.writeBytes(v)
+	case time.Time:
+		w.writeTime(v)
+	case []string:
+		w.writeArrayHeader(len(v))
+		for _, s := range v {
+			w.writeString(s)
+		}
+	case []interface{}:
+		w.writeArrayHeader(len(v))
+		for _, elem := range v {
+			encodeValue(w, elem)
+		}
+	case map[string]interface{}:
+		w.writeMapHeader(len(v))
+		for _, key := range sortedKeys(v) {
+			w.writeString(key)
+			encodeValue(w, v[key])
+		}
+	case *utils.InterfaceMap:
+		encodeValue(w, v.Map())
+	default:
+		encodeReflectValue(w, reflect.ValueOf(v))
+	}
+}
+
+func encodeReflectValue(w binaryWriter, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		w.writeArrayHeader(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			encodeValue(w, v.Index(i).Interface())
+		}
+	case reflect.Map:
+		keys := make([]string, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for i, key := range v.MapKeys() {
+			keys[i] = fmt.Sprint(key.Interface())
+			values[keys[i]] = v.MapIndex(key)
+		}
+		sort.Strings(keys)
+
+		w.writeMapHeader(len(keys))
+		for _, key := range keys {
+			w.writeString(key)
+			encodeValue(w, values[key].Interface())
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			w.writeNil()
+			return
+		}
+		encodeValue(w, v.Elem().Interface())
+	default:
+		w.writeString(fmt.Sprint(v.Interface()))
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// decodedUint returns an int if u fits, otherwise a uint64.
+func decodedUint(u uint64) interface{} {
+	if u <= math.MaxInt64 && int64(int(u)) == int64(u) {
+		return int(u)
+	}
+	return u
+}
+
+// decodedInt returns an int if i fits, otherwise an int64.
+func decodedInt(i int64) interface{} {
+	if int64(int(i)) == i {
+		return int(i)
+	}
+	return i
+}
+
+// binaryEvent creates an Event from a decoded value, which must be a map.
+func binaryEvent(v interface{}) (*event.Event, error) {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Data must be a map")
+	}
+	return eventFromMap(fields)
+}
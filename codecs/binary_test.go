@@ -0,0 +1,215 @@
+package codecs
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func binaryTestEvent() *event.Event {
+	e := event.New("hello")
+	e.SetTimestamp(time.Date(2017, time.March, 15, 10, 7, 30, 123456789, time.UTC))
+	e.SetType("app")
+	e.AddTag("a")
+	e.AddTag("b")
+	e.Set("small", 5)
+	e.Set("negative", -200)
+	e.Set("big", uint64(math.MaxUint64))
+	e.Set("ratio", 0.25)
+	e.Set("ok", true)
+	e.Set("nothing", nil)
+	e.Set("raw", []byte{0, 1, 2})
+	e.Set("headers", map[string]string{"Accept": "*/*"})
+	e.Set("nested", map[string]interface{}{"list": []int{1, 2, 3}, "long": string(bytes.Repeat([]byte{'x'}, 300))})
+	return e
+}
+
+func TestBinaryCodecsRoundTrip(t *testing.T) {
+	for _, name := range []string{"msgpack", "cbor"} {
+		c, _ := New(name, nil)
+		e := binaryTestEvent()
+
+		decoded, err := c.Decode(c.Encode(e))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if !decoded.GetTimestamp().Equal(e.GetTimestamp()) {
+			t.Errorf("%s: expected timestamp %s, got %s", name, e.GetTimestamp(), decoded.GetTimestamp())
+		}
+		if decoded.GetMessage() != "hello" || decoded.GetType() != "app" {
+			t.Errorf("%s: expected message hello and type app, got %q and %q", name, decoded.GetMessage(), decoded.GetType())
+		}
+		if !reflect.DeepEqual(decoded.GetTags(), []string{"a", "b"}) {
+			t.Errorf("%s: expected tags [a b], got %v", name, decoded.GetTags())
+		}
+
+		expected := map[string]interface{}{
+			"small":    5,
+			"negative": -200,
+			"big":      uint64(math.MaxUint64),
+			"ratio":    0.25,
+			"ok":       true,
+			"nothing":  nil,
+			"raw":      []byte{0, 1, 2},
+			"headers":  map[string]interface{}{"Accept": "*/*"},
+			"nested": map[string]interface{}{
+				"list": []interface{}{1, 2, 3},
+				"long": string(bytes.Repeat([]byte{'x'}, 300)),
+			},
+		}
+		for key, val := range expected {
+			if !reflect.DeepEqual(decoded.Get(key), val) {
+				t.Errorf("%s: expected %s=%#v, got %#v", name, key, val, decoded.Get(key))
+			}
+		}
+	}
+}
+
+func TestBinaryCodecsTimestamps(t *testing.T) {
+	times := []time.Time{
+		time.Unix(1489572450, 0),
+		time.Unix(1489572450, 1),
+		time.Unix(1<<35, 999999999),
+		time.Unix(-1000, 5),
+	}
+
+	for _, name := range []string{"msgpack", "cbor"} {
+		c, _ := New(name, nil)
+		for _, ts := range times {
+			e := event.New("")
+			e.SetTimestamp(ts)
+			decoded, err := c.Decode(c.Encode(e))
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if !decoded.GetTimestamp().Equal(ts) {
+				t.Errorf("%s: expected timestamp %s, got %s", name, ts, decoded.GetTimestamp())
+			}
+		}
+	}
+}
+
+func TestCBORDecodeForeign(t *testing.T) {
+	c, _ := New("cbor", nil)
+
+	// {_ "message": (_ "hel", "lo"), "@timestamp": 1(1489572450.5), "n": -1, "h": 1.5 (half float)}
+	data := []byte{0xbf,
+		0x67, 'm', 'e', 's', 's', 'a', 'g', 'e', 0x7f, 0x63, 'h', 'e', 'l', 0x62, 'l', 'o', 0xff,
+		0x6a, '@', 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', 0xc1, 0xfb, 0x41, 0xd6, 0x32, 0x44, 0x98, 0xa0, 0x00, 0x00,
+		0x61, 'n', 0x20,
+		0x61, 'h', 0xf9, 0x3e, 0x00,
+		0xff}
+
+	e, err := c.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.GetMessage() != "hello" {
+		t.Errorf("Expected message hello, got %q", e.GetMessage())
+	}
+	if !e.GetTimestamp().Equal(time.Unix(1489572450, 500000000)) {
+		t.Errorf("Unexpected timestamp %s", e.GetTimestamp())
+	}
+	if e.Get("n") != -1 || e.Get("h") != 1.5 {
+		t.Errorf("Expected n=-1 and h=1.5, got %#v and %#v", e.Get("n"), e.Get("h"))
+	}
+}
+
+func TestBinaryCodecsInvalid(t *testing.T) {
+	tests := map[string][][]byte{
+		"msgpack": {
+			{},
+			{0x81, 0xa1, 'a'},                      // Truncated map
+			{0x92, 0x01},                           // Truncated array
+			{0xdb, 0xff, 0xff, 0xff, 0xff},         // String longer than data
+			{0x01},                                 // Not a map
+			{0x80, 0x80},                           // Extra data
+			{0xc1},                                 // Unused type
+			{0x81, 0xa4, 't', 'a', 'g', 's', 0x01}, // Invalid tags
+		},
+		"cbor": {
+			{},
+			{0xa1, 0x61, 'a'},
+			{0x9f, 0x01},
+			{0x7b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			{0x01},
+			{0xa0, 0xa0},
+			{0x1f},
+			{0xa1, 0x61, 'a', 0xc0, 0x01}, // Time tag without a string
+		},
+	}
+
+	for name, inputs := range tests {
+		c, _ := New(name, nil)
+		for _, data := range inputs {
+			if _, err := c.Decode(data); err == nil {
+				t.Errorf("%s: expected error decoding % x", name, data)
+			}
+		}
+	}
+
+	// Deeply nested arrays
+	deep := append([]byte{0x81, 0xa1, 'a'}, bytes.Repeat([]byte{0x91}, maxBinaryDepth+10)...)
+	deep = append(deep, 0x01)
+	c, _ := New("msgpack", nil)
+	if _, err := c.Decode(deep); err == nil {
+		t.Error("Expected error decoding deeply nested data")
+	}
+}
+
+func TestBinaryCodecsFrameLength(t *testing.T) {
+	for _, name := range []string{"msgpack", "cbor"} {
+		c, _ := New(name, nil)
+		framed := c.(Framed)
+		data := c.Encode(binaryTestEvent())
+		stream := append(append([]byte(nil), data...), data...)
+
+		if n, err := framed.FrameLength(stream); n != len(data) || err != nil {
+			t.Errorf("%s: expected length %d, got %d, %v", name, len(data), n, err)
+		}
+		for i := 0; i < len(data); i++ {
+			if n, err := framed.FrameLength(data[:i]); n != 0 || err != nil {
+				t.Errorf("%s: expected no frame in %d bytes, got %d, %v", name, i, n, err)
+				break
+			}
+		}
+	}
+
+	c, _ := New("msgpack", nil)
+	if _, err := c.(Framed).FrameLength([]byte{0xc1}); err == nil {
+		t.Error("Expected error for an unused MessagePack type")
+	}
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	for _, name := range []string{"json", "msgpack", "cbor"} {
+		c, _ := New(name, nil)
+		e := binaryTestEvent()
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.Encode(e)
+			}
+		})
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	for _, name := range []string{"json", "msgpack", "cbor"} {
+		c, _ := New(name, nil)
+		data := c.Encode(binaryTestEvent())
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Decode(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// The CBORCodec encodes/decodes an event as a CBOR map. @timestamp is written as
+// an RFC3339 string with tag 0 keeping nanoseconds. Epoch times with tag 1 are
+// also decoded. Maps and arrays in fields are kept, integers are decoded as ints.
+type CBORCodec struct{}
+
+func init() {
+	register("cbor", newCBORCodec)
+}
+
+func newCBORCodec(options map[string]interface{}) (Codec, error) {
+	if err := checkBinaryOptions(options); err != nil {
+		return nil, err
+	}
+	return &CBORCodec{}, nil
+}
+
+// CBOR major types
+const (
+	cborUint   byte = 0 << 5
+	cborNegInt byte = 1 << 5
+	cborBytes  byte = 2 << 5
+	cborText   byte = 3 << 5
+	cborArray  byte = 4 << 5
+	cborMap    byte = 5 << 5
+	cborTag    byte = 6 << 5
+	cborSimple byte = 7 << 5
+)
+
+const (
+	cborTagTime      = 0
+	cborTagEpochTime = 1
+
+	// cborIndefinite is the additional information value of indefinite length items.
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// Encode Event as a CBOR map.
+func (c *CBORCodec) Encode(e *event.Event) []byte {
+	w := &cborWriter{buf: make([]byte, 0, 256)}
+	encodeEvent(w, e)
+	return w.buf
+}
+
+// Decode a CBOR map into an Event.
+func (c *CBORCodec) Decode(data []byte) (*event.Event, error) {
+	r := &cborReader{data: data}
+	v, err := r.read(0)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CBOR: %v", err)
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("Invalid CBOR: %d bytes after data", len(data)-r.pos)
+	}
+	return binaryEvent(v)
+}
+
+// FrameLength returns the length of the first CBOR item in data.
+func (c *CBORCodec) FrameLength(data []byte) (int, error) {
+	r := &cborReader{data: data}
+	if _, err := r.read(0); err != nil {
+		if err == errBinaryTruncated {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Invalid CBOR: %v", err)
+	}
+	return r.pos, nil
+}
+
+type cborWriter struct {
+	buf []byte
+}
+
+// writeHeader writes a major type with the argument n.
+func (w *cborWriter) writeHeader(major byte, n uint64) {
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, major|25)
+		w.buf = appendUint16(w.buf, uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, major|26)
+		w.buf = appendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, major|27)
+		w.buf = appendUint64(w.buf, n)
+	}
+}
+
+func (w *cborWriter) writeNil() {
+	w.buf = append(w.buf, cborSimple|22)
+}
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, cborSimple|21)
+	} else {
+		w.buf = append(w.buf, cborSimple|20)
+	}
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i >= 0 {
+		w.writeHeader(cborUint, uint64(i))
+	} else {
+		w.writeHeader(cborNegInt, uint64(-1-i))
+	}
+}
+
+func (w *cborWriter) writeUint(u uint64) {
+	w.writeHeader(cborUint, u)
+}
+
+func (w *cborWriter) writeFloat(f float64) {
+	w.buf = append(w.buf, cborSimple|27)
+	w.buf = appendUint64(w.buf, math.Float64bits(f))
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.writeHeader(cborText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.writeHeader(cborBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *cborWriter) writeTime(t time.Time) {
+	w.writeHeader(cborTag, cborTagTime)
+	w.writeString(t.Format(time.RFC3339Nano))
+}
+
+func (w *cborWriter) writeArrayHeader(n int) {
+	w.writeHeader(cborArray, uint64(n))
+}
+
+func (w *cborWriter) writeMapHeader(n int) {
+	w.writeHeader(cborMap, uint64(n))
+}
+
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errBinaryTruncated
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// header reads an item's major type and argument. indefinite is true for
+// indefinite length items.
+func (r *cborReader) header() (major byte, info byte, arg uint64, indefinite bool, err error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major = b[0] & 0xe0
+	info = b[0] & 0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		b, err := r.next(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		for _, c := range b {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, info, arg, false, nil
+	case info == cborIndefinite && major != cborUint && major != cborNegInt && major != cborTag:
+		return major, info, 0, true, nil
+	}
+	return 0, 0, 0, false, fmt.Errorf("invalid additional information %d", info)
+}
+
+// atBreak consumes a break marker if it's next.
+func (r *cborReader) atBreak() (bool, error) {
+	if r.pos >= len(r.data) {
+		return false, errBinaryTruncated
+	}
+	if r.data[r.pos] == cborBreak {
+		r.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// read decodes the next item. depth is how deeply the item is nested.
+func (r *cborReader) read(depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, errBinaryTooDeep
+	}
+
+	major, info, arg, indefinite, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return decodedUint(arg), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return -1 - float64(arg), nil
+		}
+		return decodedInt(-1 - int64(arg)), nil
+	case cborBytes, cborText:
+		b, err := r.readString(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+	case cborArray:
+		return r.readArray(arg, indefinite, depth)
+	case cborMap:
+		return r.readMap(arg, indefinite, depth)
+	case cborTag:
+		return r.readTag(arg, depth)
+	}
+
+	// Simple values and floats
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfToFloat(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	}
+	if indefinite {
+		return nil, errors.New("unexpected break")
+	}
+	return nil, fmt.Errorf("unsupported simple value %d", arg)
+}
+
+// readString reads the data of a byte or text string. Indefinite length
+// strings are made of definite length chunks of the same type.
+func (r *cborReader) readString(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		b, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	}
+
+	var buf []byte
+	for {
+		if done, err := r.atBreak(); err != nil {
+			return nil, err
+		} else if done {
+			return buf, nil
+		}
+
+		chunkMajor, _, chunkLen, chunkIndefinite, err := r.header()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, errors.New("invalid string chunk")
+		}
+		b, err := r.next(chunkLen)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b...)
+	}
+}
+
+func (r *cborReader) readArray(n uint64, indefinite bool, depth int) (interface{}, error) {
+	if !indefinite && n > uint64(len(r.data)-r.pos) {
+		return nil, errBinaryTruncated
+	}
+
+	array := make([]interface{}, 0, int(n))
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			if done, err := r.atBreak(); err != nil {
+				return nil, err
+			} else if done {
+				break
+			}
+		}
+
+		v, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, v)
+	}
+	return array, nil
+}
+
+func (r *cborReader) readMap(n uint64, indefinite bool, depth int) (interface{}, error) {
+	if !indefinite && n > uint64(len(r.data)-r.pos) {
+		return nil, errBinaryTruncated
+	}
+
+	m := make(map[string]interface{}, int(n))
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			if done, err := r.atBreak(); err != nil {
+				return nil, err
+			} else if done {
+				break
+			}
+		}
+
+		key, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		val, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := key.(string); ok {
+			m[s] = val
+		} else {
+			m[fmt.Sprint(key)] = val
+		}
+	}
+	return m, nil
+}
+
+// readTag decodes a tagged item. Times are decoded as times, other tags
+// are ignored and the item is returned.
+func (r *cborReader) readTag(tag uint64, depth int) (interface{}, error) {
+	v, err := r.read(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case cborTagTime:
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("time tag must contain a string")
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	case cborTagEpochTime:
+		switch v := v.(type) {
+		case int:
+			return time.Unix(int64(v), 0), nil
+		case int64:
+			return time.Unix(v, 0), nil
+		case float64:
+			sec := math.Floor(v)
+			return time.Unix(int64(sec), int64((v-sec)*float64(time.Second))), nil
+		}
+		return nil, errors.New("epoch time tag must contain a number")
+	}
+	return v, nil
+}
+
+// halfToFloat converts an IEEE 754 half precision float to a float64.
+func halfToFloat(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	frac := float64(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(frac, -24)
+	case 0x1f:
+		if frac == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(frac+1024, exp-25)
+}
@@ -0,0 +1,143 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// A charset converts text between UTF-8, used in Events, and another character set.
+type charset struct {
+	// max is the largest code point in the character set.
+	max rune
+	// decode converts a byte of the character set to a code point.
+	decode func(b byte) rune
+}
+
+var (
+	latin1Charset = &charset{
+		max:    0xFF,
+		decode: func(b byte) rune { return rune(b) },
+	}
+	asciiCharset = &charset{
+		max: 0x7F,
+		decode: func(b byte) rune {
+			if b > 0x7F {
+				return utf8.RuneError
+			}
+			return rune(b)
+		},
+	}
+
+	// Character sets supported by the charset codec option. UTF-8 needs no conversion.
+	charsets = map[string]*charset{
+		"utf-8":      nil,
+		"utf8":       nil,
+		"iso-8859-1": latin1Charset,
+		"latin1":     latin1Charset,
+		"us-ascii":   asciiCharset,
+		"ascii":      asciiCharset,
+	}
+)
+
+// toUTF8 converts data from the character set to UTF-8.
+func (c *charset) toUTF8(data []byte) []byte {
+	buf := make([]byte, 0, len(data))
+	for _, b := range data {
+		r := c.decode(b)
+		if r < utf8.RuneSelf {
+			buf = append(buf, byte(r))
+			continue
+		}
+		var enc [utf8.UTFMax]byte
+		n := utf8.EncodeRune(enc[:], r)
+		buf = append(buf, enc[:n]...)
+	}
+	return buf
+}
+
+// fromUTF8 converts UTF-8 data to the character set. Characters that
+// can't be represented are replaced with '?'.
+func (c *charset) fromUTF8(data []byte) []byte {
+	buf := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		if r > c.max || (r == utf8.RuneError && size == 1) {
+			buf = append(buf, '?')
+			continue
+		}
+		buf = append(buf, byte(r))
+	}
+	return buf
+}
+
+// wrapCharset wraps codec to convert to and from the character set in the charset
+// option. Codecs are returned unchanged if the option isn't set or is UTF-8.
+func wrapCharset(codec Codec, options map[string]interface{}) (Codec, error) {
+	s, exists := options["charset"]
+	if !exists {
+		return codec, nil
+	}
+
+	name, ok := s.(string)
+	if !ok {
+		return nil, errors.New("charset must be a string")
+	}
+	cs, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a supported charset", name)
+	}
+	if cs == nil {
+		return codec, nil
+	}
+
+	wrapped := &charsetCodec{Codec: codec, charset: cs}
+	if flusher, ok := codec.(Flusher); ok {
+		return &charsetFlusher{charsetCodec: wrapped, flusher: flusher}, nil
+	}
+	if delimited, ok := codec.(Delimited); ok {
+		return &charsetDelimited{charsetCodec: wrapped, delimiter: cs.fromUTF8(delimited.Delimiter())}, nil
+	}
+	return wrapped, nil
+}
+
+type charsetCodec struct {
+	Codec
+	charset *charset
+}
+
+func (c *charsetCodec) Encode(e *event.Event) []byte {
+	return c.charset.fromUTF8(c.Codec.Encode(e))
+}
+
+func (c *charsetCodec) Decode(data []byte) (*event.Event, error) {
+	return c.Codec.Decode(c.charset.toUTF8(data))
+}
+
+type charsetFlusher struct {
+	*charsetCodec
+	flusher Flusher
+}
+
+func (c *charsetFlusher) Flush() *event.Event {
+	return c.flusher.Flush()
+}
+
+func (c *charsetFlusher) FlushInterval() time.Duration {
+	return c.flusher.FlushInterval()
+}
+
+type charsetDelimited struct {
+	*charsetCodec
+	delimiter []byte
+}
+
+// Delimiter returns the delimiter in the character set.
+func (c *charsetDelimited) Delimiter() []byte {
+	return c.delimiter
+}
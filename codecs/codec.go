@@ -2,6 +2,8 @@ package codecs
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/lfkeitel/spartan/event"
 )
@@ -17,7 +19,43 @@ type Codec interface {
 	Decode(data []byte) (*event.Event, error)
 }
 
-type codecInitFunc func() (Codec, error)
+// A Delimited Codec encodes each Event followed by a delimiter. Inputs that split
+// data into frames use the delimiter if they aren't given one.
+type Delimited interface {
+	Codec
+
+	// Delimiter returns the delimiter ending each encoded Event.
+	Delimiter() []byte
+}
+
+// A Framed Codec encodes Events as self-delimiting data, such as binary data that
+// may contain any byte. Inputs split streams with FrameLength instead of a delimiter.
+type Framed interface {
+	Codec
+
+	// FrameLength returns the length of the first encoded Event in data. Zero is
+	// returned if data doesn't hold a complete Event yet. An error is returned if
+	// data isn't valid.
+	FrameLength(data []byte) (int, error)
+}
+
+// A Flusher is a Codec that holds data between calls to Decode, such as a Codec
+// that combines several lines into one Event. Inputs using a Flusher call Flush
+// when the Codec has waited FlushInterval for more data and before they stop so
+// held data isn't lost.
+type Flusher interface {
+	Codec
+
+	// Flush returns an Event made from the data held by the Codec, or nil
+	// if no data is held.
+	Flush() *event.Event
+
+	// FlushInterval is how long held data may wait for more data. Zero means
+	// data may wait indefinitely.
+	FlushInterval() time.Duration
+}
+
+type codecInitFunc func(map[string]interface{}) (Codec, error)
 
 var (
 	registeredCodecInits map[string]codecInitFunc
@@ -38,11 +76,42 @@ func register(name string, c codecInitFunc) {
 	registeredCodecInits[name] = c
 }
 
-// New will create an instance of the codec registered as name.
-func New(name string) (Codec, error) {
+// New will create an instance of the codec registered as name configured
+// with options. Options may be nil.
+func New(name string, options map[string]interface{}) (Codec, error) {
 	c, exists := registeredCodecInits[name]
 	if !exists {
 		return nil, ErrCodecNotRegistered
 	}
-	return c()
+	if options == nil {
+		options = make(map[string]interface{})
+	}
+
+	codec, err := c(options)
+	if err != nil {
+		return nil, err
+	}
+	return wrapCharset(codec, options)
+}
+
+// A Def is the codec name and options given in a module's codec option.
+type Def struct {
+	Name    string
+	Options map[string]interface{}
+}
+
+// ParseDef creates the definition of codec name with options. The codec is
+// created once to check the options are valid.
+func ParseDef(name string, options map[string]interface{}) (*Def, error) {
+	d := &Def{Name: name, Options: options}
+	if _, err := d.New(); err != nil {
+		return nil, fmt.Errorf("codec %s: %v", d.Name, err)
+	}
+	return d, nil
+}
+
+// New creates an instance of the codec. Codecs that hold data between calls,
+// such as the multiline codec, need an instance for each stream of data.
+func (d *Def) New() (Codec, error) {
+	return New(d.Name, d.Options)
 }
@@ -0,0 +1,79 @@
+package codecs
+
+import (
+	"testing"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestParseDef(t *testing.T) {
+	def, err := ParseDef("json", map[string]interface{}{"fields": []string{"message"}, "pretty": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := def.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := event.New("hello")
+	e.Set("host", "example")
+	if encoded := string(c.Encode(e)); encoded != `{"message":"hello"}` {
+		t.Errorf(`Expected {"message":"hello"}, got %s`, encoded)
+	}
+
+	invalid := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{"nonexistent", nil},
+		{"json", map[string]interface{}{"pretty": "yes"}},
+		{"json", map[string]interface{}{"charset": "ebcdic"}},
+		{"multiline", nil},
+		{"msgpack", map[string]interface{}{"charset": "ISO-8859-1"}},
+		{"cbor", map[string]interface{}{"charset": "UTF-8"}},
+	}
+	for _, test := range invalid {
+		if _, err := ParseDef(test.name, test.options); err == nil {
+			t.Errorf("Expected error for codec %s %v", test.name, test.options)
+		}
+	}
+}
+
+func TestCharset(t *testing.T) {
+	c, err := New("json", map[string]interface{}{"charset": "ISO-8859-1", "fields": "message"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := c.Decode([]byte("{\"message\":\"caf\xe9\"}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.GetMessage() != "café" {
+		t.Errorf("Expected message café, got %q", e.GetMessage())
+	}
+
+	e.SetMessage("café ☕")
+	if encoded := string(c.Encode(e)); encoded != "{\"message\":\"caf\xe9 ?\"}" {
+		t.Errorf("Expected Latin-1 encoding, got %q", encoded)
+	}
+
+	m, err := New("multiline", map[string]interface{}{"pattern": "^ ", "what": "previous", "charset": "ascii"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(Flusher); !ok {
+		t.Error("Expected multiline codec with a charset to be a Flusher")
+	}
+
+	l, err := New("line", map[string]interface{}{"delimiter": "§", "charset": "latin1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := l.(Delimited); !ok {
+		t.Error("Expected line codec with a charset to be Delimited")
+	} else if string(d.Delimiter()) != "\xa7" {
+		t.Errorf("Expected Latin-1 delimiter, got %q", d.Delimiter())
+	}
+}
@@ -0,0 +1,147 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// The CSVCodec encodes/decodes an event as a CSV line. Encoded columns are the
+// values of the fields named by columns, missing fields are empty. Decoded
+// columns are set as the fields named by columns, extra columns are named
+// column1, column2, etc. by their position. The columns @timestamp (RFC3339),
+// message, type, and tags (comma separated) set the protected fields.
+//
+// Options:
+//
+//	columns => ["@timestamp", "host", "message"]    (default ["message"])
+//	separator => ","
+//	quote_char => "\""
+//	include_header => false           (encode the column names before the first event)
+type CSVCodec struct {
+	columns       []string
+	separator     rune
+	quote         rune
+	includeHeader bool
+
+	headerLock  sync.Mutex
+	wroteHeader bool
+}
+
+func init() {
+	register("csv", newCSVCodec)
+}
+
+func newCSVCodec(options map[string]interface{}) (Codec, error) {
+	c := &CSVCodec{
+		columns:   []string{"message"},
+		separator: ',',
+		quote:     '"',
+	}
+
+	if s, exists := options["columns"]; exists {
+		switch s := s.(type) {
+		case string:
+			c.columns = []string{s}
+		case []string:
+			c.columns = s
+		default:
+			return nil, errors.New("columns must be a string or array of strings")
+		}
+	}
+
+	for _, opt := range []struct {
+		name string
+		val  *rune
+	}{
+		{"separator", &c.separator},
+		{"quote_char", &c.quote},
+	} {
+		if s, exists := options[opt.name]; exists {
+			str, ok := s.(string)
+			if !ok || utf8.RuneCountInString(str) != 1 {
+				return nil, fmt.Errorf("%s must be a single character", opt.name)
+			}
+			*opt.val, _ = utf8.DecodeRuneInString(str)
+		}
+	}
+	if c.separator == c.quote {
+		return nil, errors.New("separator and quote_char must be different")
+	}
+
+	if s, exists := options["include_header"]; exists {
+		b, ok := s.(bool)
+		if !ok {
+			return nil, errors.New("include_header must be a boolean")
+		}
+		c.includeHeader = b
+	}
+
+	return c, nil
+}
+
+// Encode Event as a CSV line. The first Event is preceded by a header line if
+// include_header is set.
+func (c *CSVCodec) Encode(e *event.Event) []byte {
+	data := e.Squash()
+
+	values := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		switch v := data.Get(column).(type) {
+		case nil:
+		case []string:
+			values[i] = strings.Join(v, ",")
+		default:
+			values[i] = event.ValueString(v)
+		}
+	}
+	line := utils.JoinCSV(values, c.separator, c.quote)
+
+	if c.includeHeader {
+		c.headerLock.Lock()
+		defer c.headerLock.Unlock()
+		if !c.wroteHeader {
+			c.wroteHeader = true
+			line = utils.JoinCSV(c.columns, c.separator, c.quote) + "\n" + line
+		}
+	}
+	return []byte(line)
+}
+
+// Decode a CSV line into an Event.
+func (c *CSVCodec) Decode(data []byte) (*event.Event, error) {
+	values, err := utils.SplitCSV(string(data), c.separator, c.quote)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for i, val := range values {
+		if i < len(c.columns) {
+			if c.columns[i] == "tags" {
+				fields["tags"] = splitTags(val)
+				continue
+			}
+			fields[c.columns[i]] = val
+		} else {
+			fields[fmt.Sprintf("column%d", i+1)] = val
+		}
+	}
+	return eventFromMap(fields)
+}
+
+// splitTags splits a comma separated list of tags.
+func splitTags(s string) []interface{} {
+	tags := []interface{}{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
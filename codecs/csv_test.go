@@ -0,0 +1,58 @@
+package codecs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestCSVEncode(t *testing.T) {
+	c, _ := New("csv", map[string]interface{}{
+		"columns":        []string{"@timestamp", "host", "message", "tags", "missing"},
+		"include_header": true,
+	})
+	e := event.New(`GET "/a,b"`)
+	e.SetTimestamp(time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC))
+	e.Set("host", "web1")
+	e.AddTag("a")
+	e.AddTag("b")
+
+	expected := "@timestamp,host,message,tags,missing\n" +
+		`2017-03-15T10:07:30.000Z,web1,"GET ""/a,b""","a,b",`
+	if encoded := string(c.Encode(e)); encoded != expected {
+		t.Errorf("Expected %s\ngot      %s", expected, encoded)
+	}
+	expected = `2017-03-15T10:07:30.000Z,web1,"GET ""/a,b""","a,b",`
+	if encoded := string(c.Encode(e)); encoded != expected {
+		t.Errorf("Expected %s\ngot      %s", expected, encoded)
+	}
+}
+
+func TestCSVDecode(t *testing.T) {
+	c, _ := New("csv", map[string]interface{}{
+		"columns":   []string{"@timestamp", "message", "tags"},
+		"separator": "|",
+	})
+	e, err := c.Decode([]byte("2017-03-15T10:07:30Z|hello|a,b|extra\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !e.GetTimestamp().Equal(time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp %s", e.GetTimestamp())
+	}
+	if e.GetMessage() != "hello" || e.Get("column4") != "extra" {
+		t.Errorf("Expected message hello and column4 extra, got %q and %#v", e.GetMessage(), e.Get("column4"))
+	}
+	if !reflect.DeepEqual(e.GetTags(), []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b], got %v", e.GetTags())
+	}
+
+	for _, data := range []string{`"bad|quote`, "yesterday|hello"} {
+		if _, err := c.Decode([]byte(data)); err == nil {
+			t.Errorf("Expected error decoding %q", data)
+		}
+	}
+}
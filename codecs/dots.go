@@ -9,7 +9,7 @@ func init() {
 	register("dot", newDotCodec)
 }
 
-func newDotCodec() (Codec, error) {
+func newDotCodec(options map[string]interface{}) (Codec, error) {
 	return &DotCodec{}, nil
 }
 
@@ -0,0 +1,107 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// eventFromMap creates an Event from decoded fields. The keys @timestamp, message,
+// type, and tags set the protected fields, all other keys are set as fields.
+// @timestamp may be a time, an RFC3339 string, or a number of seconds since the
+// Unix epoch. Numbers too large to be seconds are taken as milliseconds.
+func eventFromMap(fields map[string]interface{}) (*event.Event, error) {
+	e := event.New("")
+	for key, val := range fields {
+		if val == nil && isProtectedField(key) {
+			continue
+		}
+
+		switch key {
+		case "@timestamp":
+			t, err := parseTimestamp(val)
+			if err != nil {
+				return nil, err
+			}
+			e.SetTimestamp(t)
+		case "message":
+			message, ok := val.(string)
+			if !ok {
+				return nil, errors.New("message must be a string")
+			}
+			e.SetMessage(message)
+		case "type":
+			etype, ok := val.(string)
+			if !ok {
+				return nil, errors.New("type must be a string")
+			}
+			e.SetType(etype)
+		case "tags":
+			tags, err := tagsValue(val)
+			if err != nil {
+				return nil, err
+			}
+			for _, tag := range tags {
+				e.AddTag(tag)
+			}
+		default:
+			e.Set(key, val)
+		}
+	}
+	return e, nil
+}
+
+func isProtectedField(key string) bool {
+	return key == "@timestamp" || key == "message" || key == "type" || key == "tags"
+}
+
+// maxEpochSeconds is the largest @timestamp number taken as seconds, about the year 5138.
+const maxEpochSeconds = 1e11
+
+// parseTimestamp converts a decoded @timestamp value to a time.
+func parseTimestamp(val interface{}) (time.Time, error) {
+	switch val := val.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("@timestamp must be an RFC3339 time: %v", err)
+		}
+		return t, nil
+	case int:
+		if val > maxEpochSeconds || val < -maxEpochSeconds {
+			return time.Unix(0, int64(val)*int64(time.Millisecond)), nil
+		}
+		return time.Unix(int64(val), 0), nil
+	case float64:
+		if val > maxEpochSeconds || val < -maxEpochSeconds {
+			val /= 1000
+		}
+		sec := math.Floor(val)
+		return time.Unix(int64(sec), int64((val-sec)*float64(time.Second))), nil
+	}
+	return time.Time{}, errors.New("@timestamp must be a string or number")
+}
+
+// tagsValue converts a decoded tags value, a string or array of strings.
+func tagsValue(val interface{}) ([]string, error) {
+	switch val := val.(type) {
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		tags := make([]string, len(val))
+		for i, tag := range val {
+			s, ok := tag.(string)
+			if !ok {
+				return nil, errors.New("tags must be a string or array of strings")
+			}
+			tags[i] = s
+		}
+		return tags, nil
+	}
+	return nil, errors.New("tags must be a string or array of strings")
+}
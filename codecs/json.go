@@ -1,32 +1,180 @@
 package codecs
 
 import (
+	"bytes"
 	"encoding/json"
-	"time"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
 
 	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
 )
 
 // The JSONCodec encodes/decodes an event as JSON.
-type JSONCodec struct{}
+//
+// Options:
+//
+//	pretty => false                    (indent the encoded JSON)
+//	fields => ["message", "host"]      (encode only these fields, all by default)
+//	timestamp_precision => "millis"    ("seconds", "millis", "micros", or "nanos")
+type JSONCodec struct {
+	config *jsonConfig
+}
+
+type jsonConfig struct {
+	pretty          bool
+	fields          []string
+	timestampFormat string
+}
 
 func init() {
 	register("json", newJSONCodec)
 }
 
-func newJSONCodec() (Codec, error) {
-	return &JSONCodec{}, nil
+func newJSONCodec(options map[string]interface{}) (Codec, error) {
+	return NewJSONCodec(options)
+}
+
+// NewJSONCodec creates a JSONCodec configured with options.
+func NewJSONCodec(options map[string]interface{}) (*JSONCodec, error) {
+	c := &JSONCodec{config: &jsonConfig{}}
+	if err := c.config.setConfig(options); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *jsonConfig) setConfig(options map[string]interface{}) error {
+	if s, exists := options["pretty"]; exists {
+		pretty, ok := s.(bool)
+		if !ok {
+			return errors.New("pretty must be a boolean")
+		}
+		c.pretty = pretty
+	}
+
+	if s, exists := options["fields"]; exists {
+		switch s := s.(type) {
+		case string:
+			c.fields = []string{s}
+		case []string:
+			c.fields = s
+		default:
+			return errors.New("fields must be a string or array of strings")
+		}
+	}
+
+	format, err := timestampFormatOption(options)
+	if err != nil {
+		return err
+	}
+	c.timestampFormat = format
+	return nil
 }
 
 // Encode Event as JSON object.
 func (c *JSONCodec) Encode(e *event.Event) []byte {
-	e.SetTimestamp(time.Unix(e.GetTimestamp().Unix(), 0))
-	data := e.Squash()
+	data := squashJSON(e, c.config)
+	if c.config.pretty {
+		j, _ := json.MarshalIndent(data, "", "  ")
+		return j
+	}
 	j, _ := json.Marshal(data)
 	return j
 }
 
-// Decode byte slice into an Event. CURRENTLY NOT IMPLEMENTED.
+var timestampFormats = map[string]string{
+	"seconds": "2006-01-02T15:04:05Z07:00",
+	"millis":  "2006-01-02T15:04:05.000Z07:00",
+	"micros":  "2006-01-02T15:04:05.000000Z07:00",
+	"nanos":   "2006-01-02T15:04:05.000000000Z07:00",
+}
+
+// timestampFormatOption returns the time layout for the timestamp_precision
+// option, millisecond precision by default.
+func timestampFormatOption(options map[string]interface{}) (string, error) {
+	precision := "millis"
+	if s, exists := options["timestamp_precision"]; exists {
+		p, ok := s.(string)
+		if !ok {
+			return "", errors.New("timestamp_precision must be a string")
+		}
+		precision = p
+	}
+
+	format, ok := timestampFormats[precision]
+	if !ok {
+		return "", fmt.Errorf("%s is not a valid timestamp_precision", precision)
+	}
+	return format, nil
+}
+
+// squashJSON squashes e with @timestamp formatted for the config. If fields
+// are configured only those fields are included. The Event isn't modified.
+func squashJSON(e *event.Event, c *jsonConfig) *utils.InterfaceMap {
+	data := e.Squash()
+	data.Set("@timestamp", e.GetTimestamp().Format(c.timestampFormat))
+	if c.fields == nil {
+		return data
+	}
+
+	selected := utils.NewInterfaceMap()
+	for _, field := range c.fields {
+		if val, exists := data.GetOK(field); exists {
+			selected.Set(field, val)
+		}
+	}
+	return selected
+}
+
+// Decode a JSON object into an Event. See decodeJSON.
 func (c *JSONCodec) Decode(data []byte) (*event.Event, error) {
-	return nil, nil
+	return decodeJSON(data)
+}
+
+// decodeJSON creates an Event from a JSON object as described by eventFromMap.
+// Integers are decoded as ints, other numbers as float64s.
+func decodeJSON(data []byte) (*event.Event, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("Invalid JSON: %v", err)
+	}
+	if fields == nil {
+		return nil, errors.New("Invalid JSON: expected an object")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("Invalid JSON: unexpected data after object")
+	}
+
+	for key, val := range fields {
+		fields[key] = convertJSONNumbers(val)
+	}
+	return eventFromMap(fields)
+}
+
+// convertJSONNumbers replaces json.Numbers in val with an int if the number
+// is whole and fits, otherwise a float64.
+func convertJSONNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(string(v)); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = convertJSONNumbers(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = convertJSONNumbers(elem)
+		}
+	}
+	return val
 }
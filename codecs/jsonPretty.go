@@ -1,32 +1,26 @@
 package codecs
 
-import (
-	"encoding/json"
-	"time"
-
-	"github.com/lfkeitel/spartan/event"
-)
-
 // The JSONPrettyCodec encodes/decodes an event as formatted, pretty JSON.
-type JSONPrettyCodec struct{}
+// It's the same as a JSONCodec with the pretty option set and accepts the
+// same options.
+type JSONPrettyCodec struct {
+	*JSONCodec
+}
 
 func init() {
 	register("json_pretty", newJSONPrettyCodec)
 }
 
-func newJSONPrettyCodec() (Codec, error) {
-	return &JSONPrettyCodec{}, nil
-}
-
-// Encode Event as JSON object.
-func (c *JSONPrettyCodec) Encode(e *event.Event) []byte {
-	e.SetTimestamp(time.Unix(e.GetTimestamp().Unix(), 0))
-	data := e.Squash()
-	j, _ := json.MarshalIndent(data, "", "  ")
-	return j
+func newJSONPrettyCodec(options map[string]interface{}) (Codec, error) {
+	return NewJSONPrettyCodec(options)
 }
 
-// Decode byte slice into an Event. CURRENTLY NOT IMPLEMENTED.
-func (c *JSONPrettyCodec) Decode(data []byte) (*event.Event, error) {
-	return nil, nil
+// NewJSONPrettyCodec creates a JSONPrettyCodec configured with options.
+func NewJSONPrettyCodec(options map[string]interface{}) (*JSONPrettyCodec, error) {
+	c, err := NewJSONCodec(options)
+	if err != nil {
+		return nil, err
+	}
+	c.config.pretty = true
+	return &JSONPrettyCodec{JSONCodec: c}, nil
 }
@@ -0,0 +1,145 @@
+package codecs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestJSONDecode(t *testing.T) {
+	c, _ := New("json", nil)
+	e, err := c.Decode([]byte(`{
+		"@timestamp": "2017-03-15T10:07:30.25Z",
+		"message": "hello",
+		"type": "app",
+		"tags": ["a", "b"],
+		"count": 3,
+		"ratio": 0.5,
+		"user": {"id": 7, "groups": ["admin"]},
+		"missing": null
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTime := time.Date(2017, time.March, 15, 10, 7, 30, 250000000, time.UTC)
+	if !e.GetTimestamp().Equal(expectedTime) {
+		t.Errorf("Expected timestamp %s, got %s", expectedTime, e.GetTimestamp())
+	}
+	if e.GetMessage() != "hello" {
+		t.Errorf("Expected message hello, got %q", e.GetMessage())
+	}
+	if e.GetType() != "app" {
+		t.Errorf("Expected type app, got %q", e.GetType())
+	}
+	if !reflect.DeepEqual(e.GetTags(), []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b], got %v", e.GetTags())
+	}
+	if e.Get("count") != 3 {
+		t.Errorf("Expected count 3, got %#v", e.Get("count"))
+	}
+	if e.Get("ratio") != 0.5 {
+		t.Errorf("Expected ratio 0.5, got %#v", e.Get("ratio"))
+	}
+	user := map[string]interface{}{"id": 7, "groups": []interface{}{"admin"}}
+	if !reflect.DeepEqual(e.Get("user"), user) {
+		t.Errorf("Expected user %v, got %#v", user, e.Get("user"))
+	}
+	if e.Get("missing") != nil {
+		t.Errorf("Expected missing to be nil, got %#v", e.Get("missing"))
+	}
+}
+
+func TestJSONDecodeEpoch(t *testing.T) {
+	c, _ := New("json_pretty", nil)
+	tests := map[string]time.Time{
+		`{"@timestamp": 1489572450}`:                  time.Unix(1489572450, 0),
+		`{"@timestamp": 1489572450.5}`:                time.Unix(1489572450, 500000000),
+		`{"@timestamp": 1489572450250}`:               time.Unix(1489572450, 250000000),
+		`{"@timestamp": "2017-03-15T10:07:30+02:00"}`: time.Date(2017, time.March, 15, 8, 7, 30, 0, time.UTC),
+	}
+
+	for data, expected := range tests {
+		e, err := c.Decode([]byte(data))
+		if err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+		if !e.GetTimestamp().Equal(expected) {
+			t.Errorf("%s: expected %s, got %s", data, expected, e.GetTimestamp())
+		}
+	}
+}
+
+func TestJSONDecodeInvalid(t *testing.T) {
+	c, _ := New("json", nil)
+	invalid := []string{
+		``,
+		`{"message": "unterminated`,
+		`["not", "an", "object"]`,
+		`"string"`,
+		`null`,
+		`{"message": "one"} {"message": "two"}`,
+		`{"message": 5}`,
+		`{"type": ["a"]}`,
+		`{"tags": [1, 2]}`,
+		`{"@timestamp": "yesterday"}`,
+		`{"@timestamp": true}`,
+	}
+
+	for _, data := range invalid {
+		if e, err := c.Decode([]byte(data)); err == nil {
+			t.Errorf("Expected error decoding %s, got %v", data, e.Squash())
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	c, _ := New("json", nil)
+	e, err := c.Decode([]byte(`{"@timestamp":"2017-03-15T10:07:30Z","message":"hello","tags":["a"],"type":"app","n":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := c.Encode(e)
+	e2, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded2 := c.Encode(e2); string(encoded) != string(encoded2) {
+		t.Errorf("Expected %s, got %s", encoded, encoded2)
+	}
+}
+
+func TestJSONEncodeTimestampPrecision(t *testing.T) {
+	ts := time.Date(2017, time.March, 15, 10, 7, 30, 123456789, time.UTC)
+	tests := map[string]string{
+		"seconds": `"2017-03-15T10:07:30Z"`,
+		"millis":  `"2017-03-15T10:07:30.123Z"`,
+		"micros":  `"2017-03-15T10:07:30.123456Z"`,
+		"nanos":   `"2017-03-15T10:07:30.123456789Z"`,
+	}
+
+	for precision, expected := range tests {
+		c, err := NewJSONCodec(map[string]interface{}{"timestamp_precision": precision})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := event.New("hello")
+		e.SetTimestamp(ts)
+
+		encoded := string(c.Encode(e))
+		if !strings.Contains(encoded, `"@timestamp":`+expected) {
+			t.Errorf("%s: expected @timestamp %s in %s", precision, expected, encoded)
+		}
+		if !e.GetTimestamp().Equal(ts) {
+			t.Errorf("%s: Encode modified the event timestamp to %s", precision, e.GetTimestamp())
+		}
+	}
+
+	if _, err := NewJSONPrettyCodec(map[string]interface{}{"timestamp_precision": "hours"}); err == nil {
+		t.Error("Expected error for invalid timestamp_precision")
+	}
+}
@@ -0,0 +1,252 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// The KVCodec encodes/decodes an event as key=value pairs such as
+// `@timestamp=2017-03-15T10:07:30.000Z host=web1 message="GET /index.html"`.
+// Keys and values containing separators, quotes, or whitespace are quoted, quotes
+// and backslashes in quoted text are escaped with a backslash. Tags are written as
+// a comma separated list. Decoded values are strings.
+//
+// Options:
+//
+//	field_split => " "
+//	value_split => "="
+//	quote => "\""
+//	fields => ["host", "message"]    (encode only these fields in this order)
+type KVCodec struct {
+	fieldSplit string
+	valueSplit string
+	quote      byte
+	fields     []string
+}
+
+func init() {
+	register("kv", newKVCodec)
+}
+
+func newKVCodec(options map[string]interface{}) (Codec, error) {
+	c := &KVCodec{
+		fieldSplit: " ",
+		valueSplit: "=",
+		quote:      '"',
+	}
+
+	for _, opt := range []struct {
+		name string
+		val  *string
+	}{
+		{"field_split", &c.fieldSplit},
+		{"value_split", &c.valueSplit},
+	} {
+		if s, exists := options[opt.name]; exists {
+			str, ok := s.(string)
+			if !ok || str == "" {
+				return nil, fmt.Errorf("%s must be a non-empty string", opt.name)
+			}
+			*opt.val = str
+		}
+	}
+	if c.fieldSplit == c.valueSplit {
+		return nil, errors.New("field_split and value_split must be different")
+	}
+
+	if s, exists := options["quote"]; exists {
+		quote, ok := s.(string)
+		if !ok || len(quote) != 1 {
+			return nil, errors.New("quote must be a single character")
+		}
+		c.quote = quote[0]
+	}
+
+	if s, exists := options["fields"]; exists {
+		switch s := s.(type) {
+		case string:
+			c.fields = []string{s}
+		case []string:
+			c.fields = s
+		default:
+			return nil, errors.New("fields must be a string or array of strings")
+		}
+	}
+
+	return c, nil
+}
+
+// Encode Event as key=value pairs.
+func (c *KVCodec) Encode(e *event.Event) []byte {
+	data := e.Squash()
+	data.Set("@timestamp", e.GetTimestamp().Format(timestampFormats["millis"]))
+
+	keys := c.fields
+	if keys == nil {
+		keys = data.Keys()
+		sort.Strings(keys)
+		for i, key := range keys {
+			if key == "@timestamp" {
+				// The timestamp leads the line
+				copy(keys[1:i+1], keys[:i])
+				keys[0] = key
+				break
+			}
+		}
+	}
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		val := data.Get(key)
+		var str string
+		switch v := val.(type) {
+		case nil:
+			continue
+		case []string:
+			if key == "tags" && len(v) == 0 {
+				continue
+			}
+			str = strings.Join(v, ",")
+		default:
+			str = event.ValueString(v)
+		}
+		if key == "type" && str == "" {
+			continue
+		}
+
+		pairs = append(pairs, c.quoteText(key)+c.valueSplit+c.quoteText(str))
+	}
+	return []byte(strings.Join(pairs, c.fieldSplit))
+}
+
+// quoteText quotes s if it's empty or contains separators, quotes, backslashes,
+// or whitespace.
+func (c *KVCodec) quoteText(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\\"+string(c.quote)) &&
+		!strings.Contains(s, c.fieldSplit) && !strings.Contains(s, c.valueSplit) {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s)+2)
+	buf = append(buf, c.quote)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', c.quote:
+			buf = append(buf, '\\', s[i])
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, s[i])
+		}
+	}
+	return string(append(buf, c.quote))
+}
+
+// Decode key=value pairs into an Event. Text without a value separator is
+// ignored. The keys @timestamp (RFC3339), message, type, and tags (comma
+// separated) set the protected fields.
+func (c *KVCodec) Decode(data []byte) (*event.Event, error) {
+	e := event.New("")
+	s := strings.TrimRight(string(data), "\r\n")
+
+	for {
+		for strings.HasPrefix(s, c.fieldSplit) {
+			s = s[len(c.fieldSplit):]
+		}
+		if strings.TrimSpace(s) == "" {
+			return e, nil
+		}
+
+		key, rest, err := c.nextText(s, c.valueSplit)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(rest, c.valueSplit) {
+			s = rest // No value, skip to the next pair
+			continue
+		}
+
+		val, rest, err := c.nextText(rest[len(c.valueSplit):], "")
+		if err != nil {
+			return nil, err
+		}
+		s = rest
+
+		if err := c.setField(e, strings.TrimSpace(key), val); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// nextText reads quoted text, or unquoted text up to the field separator or stop,
+// from the start of s. The text and the rest of s are returned.
+func (c *KVCodec) nextText(s, stop string) (string, string, error) {
+	if len(s) > 0 && s[0] == c.quote {
+		buf := make([]byte, 0, len(s))
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case c.quote:
+				return string(buf), s[i+1:], nil
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					switch s[i] {
+					case 'n':
+						buf = append(buf, '\n')
+					case 'r':
+						buf = append(buf, '\r')
+					case 't':
+						buf = append(buf, '\t')
+					default:
+						buf = append(buf, s[i])
+					}
+					continue
+				}
+			}
+			buf = append(buf, s[i])
+		}
+		return "", "", errors.New("Unterminated quoted text")
+	}
+
+	end := strings.Index(s, c.fieldSplit)
+	if end < 0 {
+		end = len(s)
+	}
+	if stop != "" {
+		if i := strings.Index(s[:end], stop); i >= 0 {
+			end = i
+		}
+	}
+	return s[:end], s[end:], nil
+}
+
+func (c *KVCodec) setField(e *event.Event, key, val string) error {
+	switch key {
+	case "":
+		return nil
+	case "@timestamp":
+		t, err := time.Parse(time.RFC3339Nano, val)
+		if err != nil {
+			return fmt.Errorf("@timestamp must be an RFC3339 time: %v", err)
+		}
+		e.SetTimestamp(t)
+	case "tags":
+		for _, tag := range strings.Split(val, ",") {
+			if tag != "" {
+				e.AddTag(tag)
+			}
+		}
+	default:
+		e.Set(key, val)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package codecs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestKVEncode(t *testing.T) {
+	c, _ := New("kv", nil)
+	e := event.New("GET /index.html")
+	e.SetTimestamp(time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC))
+	e.Set("Host", "web1")
+	e.Set("status", 200)
+	e.Set("quoted", `say "hi"`)
+	e.AddTag("a")
+	e.AddTag("b")
+
+	expected := `@timestamp=2017-03-15T10:07:30.000Z Host=web1 message="GET /index.html" quoted="say \"hi\"" status=200 tags=a,b`
+	if encoded := string(c.Encode(e)); encoded != expected {
+		t.Errorf("Expected %s\ngot      %s", expected, encoded)
+	}
+
+	c, _ = New("kv", map[string]interface{}{
+		"field_split": ", ",
+		"value_split": ":",
+		"quote":       "'",
+		"fields":      []string{"status", "Host", "missing"},
+	})
+	if encoded := string(c.Encode(e)); encoded != "status:200, Host:web1" {
+		t.Errorf("Expected status:200, Host:web1, got %s", encoded)
+	}
+}
+
+func TestKVDecode(t *testing.T) {
+	c, _ := New("kv", nil)
+	e, err := c.Decode([]byte(`@timestamp=2017-03-15T10:07:30Z  user=alice flag msg="two\nlines \"quoted\"" "odd key"=x tags=a,b empty=`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !e.GetTimestamp().Equal(time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp %s", e.GetTimestamp())
+	}
+	expected := map[string]interface{}{
+		"user":    "alice",
+		"msg":     "two\nlines \"quoted\"",
+		"odd key": "x",
+		"empty":   "",
+		"flag":    nil,
+	}
+	for key, val := range expected {
+		if e.Get(key) != val {
+			t.Errorf("Expected %s=%#v, got %#v", key, val, e.Get(key))
+		}
+	}
+	if !reflect.DeepEqual(e.GetTags(), []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b], got %v", e.GetTags())
+	}
+
+	for _, data := range []string{`a="unterminated`, `@timestamp=yesterday`} {
+		if _, err := c.Decode([]byte(data)); err == nil {
+			t.Errorf("Expected error decoding %s", data)
+		}
+	}
+}
+
+func TestKVRoundTrip(t *testing.T) {
+	c, _ := New("kv", map[string]interface{}{"field_split": ";"})
+	e := event.New("a;b=c \"d\"\\")
+	e.Set("path", `C:\logs`)
+
+	e2, err := c.Decode(c.Encode(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e2.GetMessage() != e.GetMessage() || e2.Get("path") != e.Get("path") {
+		t.Errorf("Round trip changed event, got message %q path %q", e2.GetMessage(), e2.Get("path"))
+	}
+}
+
+func TestPlainAndLineCodecs(t *testing.T) {
+	e := event.New("hello")
+	e.Set("host", "web1")
+
+	plain, _ := New("plain", map[string]interface{}{"format": "%{host}: %{message} %{missing}"})
+	if encoded := string(plain.Encode(e)); encoded != "web1: hello %{missing}" {
+		t.Errorf("Expected web1: hello %%{missing}, got %s", encoded)
+	}
+
+	line, err := New("line", map[string]interface{}{"delimiter": `\r\n`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded := string(line.Encode(e)); encoded != "hello\r\n" {
+		t.Errorf("Expected hello\\r\\n, got %q", encoded)
+	}
+	decoded, _ := line.Decode([]byte("hello\r\n"))
+	if decoded.GetMessage() != "hello" {
+		t.Errorf("Expected hello, got %q", decoded.GetMessage())
+	}
+}
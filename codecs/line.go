@@ -0,0 +1,65 @@
+package codecs
+
+import (
+	"bytes"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// The LineCodec encodes an event as its message followed by a delimiter. Decoded
+// data is the message of a new event with a trailing delimiter removed. Inputs
+// without their own delimiter option split data on the codec's delimiter.
+//
+// Options:
+//
+//	delimiter => "\n"                 (escapes such as \r\n and \x00 are allowed)
+//	format => "%{host}: %{message}"   (same as the plain codec)
+type LineCodec struct {
+	delimiter []byte
+	format    string
+}
+
+func init() {
+	register("line", newLineCodec)
+}
+
+func newLineCodec(options map[string]interface{}) (Codec, error) {
+	c := &LineCodec{delimiter: []byte{'\n'}}
+
+	if s, exists := options["delimiter"]; exists {
+		delim, err := utils.ParseDelimiter(s)
+		if err != nil {
+			return nil, err
+		}
+		c.delimiter = delim
+	}
+
+	format, err := formatOption(options)
+	if err != nil {
+		return nil, err
+	}
+	c.format = format
+	return c, nil
+}
+
+// Encode Event as its message or formatted text followed by the delimiter.
+func (c *LineCodec) Encode(e *event.Event) []byte {
+	var line string
+	if c.format == "" {
+		line = e.GetMessage()
+	} else {
+		line = e.Sprintf(c.format)
+	}
+	return append([]byte(line), c.delimiter...)
+}
+
+// Delimiter returns the delimiter ending each line.
+func (c *LineCodec) Delimiter() []byte {
+	return c.delimiter
+}
+
+// Decode data as the message of an Event.
+func (c *LineCodec) Decode(data []byte) (*event.Event, error) {
+	return event.New(string(bytes.TrimSuffix(data, c.delimiter))), nil
+}
@@ -0,0 +1,421 @@
+package codecs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// The MsgpackCodec encodes/decodes an event as a MessagePack map. @timestamp is
+// written with the MessagePack timestamp extension type keeping nanoseconds. Maps
+// and arrays in fields are kept, integers are decoded as ints.
+type MsgpackCodec struct{}
+
+func init() {
+	register("msgpack", newMsgpackCodec)
+}
+
+func newMsgpackCodec(options map[string]interface{}) (Codec, error) {
+	if err := checkBinaryOptions(options); err != nil {
+		return nil, err
+	}
+	return &MsgpackCodec{}, nil
+}
+
+// msgpackTimestampType is the extension type of MessagePack timestamps.
+const msgpackTimestampType = -1
+
+// Encode Event as a MessagePack map.
+func (c *MsgpackCodec) Encode(e *event.Event) []byte {
+	w := &msgpackWriter{buf: make([]byte, 0, 256)}
+	encodeEvent(w, e)
+	return w.buf
+}
+
+// Decode a MessagePack map into an Event.
+func (c *MsgpackCodec) Decode(data []byte) (*event.Event, error) {
+	r := &msgpackReader{data: data}
+	v, err := r.read(0)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid MessagePack: %v", err)
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("Invalid MessagePack: %d bytes after data", len(data)-r.pos)
+	}
+	return binaryEvent(v)
+}
+
+// FrameLength returns the length of the first MessagePack value in data.
+func (c *MsgpackCodec) FrameLength(data []byte) (int, error) {
+	r := &msgpackReader{data: data}
+	if _, err := r.read(0); err != nil {
+		if err == errBinaryTruncated {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Invalid MessagePack: %v", err)
+	}
+	return r.pos, nil
+}
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) writeNil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(i))
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1)
+		w.buf = appendUint16(w.buf, uint16(i))
+	case i >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = appendUint32(w.buf, uint32(i))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = appendUint64(w.buf, uint64(i))
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		w.buf = append(w.buf, byte(u))
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd)
+		w.buf = appendUint16(w.buf, uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce)
+		w.buf = appendUint32(w.buf, uint32(u))
+	default:
+		w.buf = append(w.buf, 0xcf)
+		w.buf = appendUint64(w.buf, u)
+	}
+}
+
+func (w *msgpackWriter) writeFloat(f float64) {
+	w.buf = append(w.buf, 0xcb)
+	w.buf = appendUint64(w.buf, math.Float64bits(f))
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xda)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdb)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xc5)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xc6)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+// writeTime writes t as a timestamp extension using the smallest format that
+// holds it.
+func (w *msgpackWriter) writeTime(t time.Time) {
+	sec := t.Unix()
+	nsec := uint32(t.Nanosecond())
+
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		w.buf = append(w.buf, 0xd6, 0xff)
+		w.buf = appendUint32(w.buf, uint32(sec))
+	case sec >= 0 && sec < 1<<34:
+		w.buf = append(w.buf, 0xd7, 0xff)
+		w.buf = appendUint64(w.buf, uint64(nsec)<<34|uint64(sec))
+	default:
+		w.buf = append(w.buf, 0xc7, 12, 0xff)
+		w.buf = appendUint32(w.buf, nsec)
+		w.buf = appendUint64(w.buf, uint64(sec))
+	}
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xdc)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdd)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xde)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdf)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next n bytes.
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, errBinaryTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// uint reads an n byte big endian unsigned integer.
+func (r *msgpackReader) uint(n int) (uint64, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// length reads an n byte length.
+func (r *msgpackReader) length(n int) (int, error) {
+	u, err := r.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(r.data)) {
+		return 0, errBinaryTruncated
+	}
+	return int(u), nil
+}
+
+// read decodes the next value. depth is how deeply the value is nested.
+func (r *msgpackReader) read(depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, errBinaryTooDeep
+	}
+
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	t := b[0]
+
+	switch {
+	case t <= 0x7f:
+		return int(t), nil
+	case t >= 0xe0:
+		return int(int8(t)), nil
+	case t&0xf0 == 0x80:
+		return r.readMap(int(t&0x0f), depth)
+	case t&0xf0 == 0x90:
+		return r.readArray(int(t&0x0f), depth)
+	case t&0xe0 == 0xa0:
+		return r.readString(int(t & 0x1f))
+	}
+
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.length(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.length(1 << (t - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.readExt(n)
+	case 0xca:
+		u, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := r.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (t - 0xcc))
+		return decodedUint(u), err
+	case 0xd0:
+		u, err := r.uint(1)
+		return int(int8(u)), err
+	case 0xd1:
+		u, err := r.uint(2)
+		return int(int16(u)), err
+	case 0xd2:
+		u, err := r.uint(4)
+		return decodedInt(int64(int32(u))), err
+	case 0xd3:
+		u, err := r.uint(8)
+		return decodedInt(int64(u)), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (t - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.length(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(n)
+	case 0xdc, 0xdd:
+		n, err := r.length(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.readArray(n, depth)
+	case 0xde, 0xdf:
+		n, err := r.length(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.readMap(n, depth)
+	}
+	return nil, fmt.Errorf("unknown type 0x%02x", t)
+}
+
+func (r *msgpackReader) readString(n int) (interface{}, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (r *msgpackReader) readArray(n, depth int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errBinaryTruncated
+	}
+	array := make([]interface{}, n)
+	for i := range array {
+		v, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array[i] = v
+	}
+	return array, nil
+}
+
+func (r *msgpackReader) readMap(n, depth int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errBinaryTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		val, err := r.read(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := key.(string); ok {
+			m[s] = val
+		} else {
+			m[fmt.Sprint(key)] = val
+		}
+	}
+	return m, nil
+}
+
+// readExt reads extension data of length n. Timestamps are decoded as times,
+// other extension types as their data.
+func (r *msgpackReader) readExt(n int) (interface{}, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	extType := int8(b[0])
+
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if extType != msgpackTimestampType {
+		return append([]byte(nil), data...), nil
+	}
+
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)), nil
+	}
+	return nil, fmt.Errorf("invalid timestamp length %d", n)
+}
+
+func appendUint16(b []byte, u uint16) []byte {
+	return append(b, byte(u>>8), byte(u))
+}
+
+func appendUint32(b []byte, u uint32) []byte {
+	return append(b, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(b []byte, u uint64) []byte {
+	return append(b, byte(u>>56), byte(u>>48), byte(u>>40), byte(u>>32),
+		byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
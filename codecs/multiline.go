@@ -0,0 +1,167 @@
+package codecs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/grok"
+)
+
+// The MultilineCodec combines consecutive lines into a single Event. Each call
+// to Decode is given one line. A line that matches pattern, or doesn't match if
+// negate is true, belongs with the previous line if what is "previous", or with
+// the next line if what is "next". Events made of more than one line are tagged
+// "multiline". Once max_lines lines are collected, an Event is created even if
+// more lines belong to it.
+//
+// Options:
+//
+//	pattern => "^\s"          (required, may reference grok patterns)
+//	negate => false
+//	what => "previous"        (required, "previous" or "next")
+//	max_lines => 500
+//	timeout => "5s"           (how long a partial Event waits for more lines)
+type MultilineCodec struct {
+	pattern  *regexp.Regexp
+	negate   bool
+	previous bool
+	maxLines int
+	timeout  time.Duration
+	lines    []string
+}
+
+func init() {
+	register("multiline", newMultilineCodec)
+}
+
+func newMultilineCodec(options map[string]interface{}) (Codec, error) {
+	c, err := NewMultilineCodec(options)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewMultilineCodec creates a MultilineCodec configured with options. A MultilineCodec
+// holds state between lines, each stream of lines needs its own instance.
+func NewMultilineCodec(options map[string]interface{}) (*MultilineCodec, error) {
+	c := &MultilineCodec{
+		maxLines: 500,
+		timeout:  5 * time.Second,
+	}
+	return c, c.setConfig(options)
+}
+
+func (c *MultilineCodec) setConfig(options map[string]interface{}) error {
+	if s, exists := options["pattern"]; exists {
+		pattern, ok := s.(string)
+		if !ok {
+			return errors.New("pattern must be a string")
+		}
+		r, err := grok.CompilePattern(pattern)
+		if err != nil {
+			return fmt.Errorf("pattern failed to compile: %v", err)
+		}
+		c.pattern = r
+	} else {
+		return errors.New("pattern option required")
+	}
+
+	if s, exists := options["negate"]; exists {
+		negate, ok := s.(bool)
+		if !ok {
+			return errors.New("negate must be true or false")
+		}
+		c.negate = negate
+	}
+
+	if s, exists := options["what"]; exists {
+		what, ok := s.(string)
+		if !ok || (what != "previous" && what != "next") {
+			return errors.New("what must be \"previous\" or \"next\"")
+		}
+		c.previous = what == "previous"
+	} else {
+		return errors.New("what option required")
+	}
+
+	if s, exists := options["max_lines"]; exists {
+		maxLines, ok := s.(int)
+		if !ok || maxLines < 1 {
+			return errors.New("max_lines must be a positive integer")
+		}
+		c.maxLines = maxLines
+	}
+
+	if s, exists := options["timeout"]; exists {
+		str, ok := s.(string)
+		if !ok {
+			return errors.New("timeout must be a duration string such as \"5s\"")
+		}
+		timeout, err := time.ParseDuration(str)
+		if err != nil || timeout < 0 {
+			return errors.New("timeout must be a duration string such as \"5s\"")
+		}
+		c.timeout = timeout
+	}
+
+	return nil
+}
+
+// Encode returns the message of the Event.
+func (c *MultilineCodec) Encode(e *event.Event) []byte {
+	return []byte(e.GetMessage())
+}
+
+// Decode adds the line data to the Event being assembled. If the line completes
+// an Event, the Event is returned. Otherwise the returned Event is nil.
+func (c *MultilineCodec) Decode(data []byte) (*event.Event, error) {
+	line := string(data)
+	match := c.pattern.MatchString(line) != c.negate
+
+	if c.previous {
+		if match && len(c.lines) > 0 && len(c.lines) < c.maxLines {
+			c.lines = append(c.lines, line)
+			return nil, nil
+		}
+		e := c.Flush()
+		c.lines = append(c.lines, line)
+		return e, nil
+	}
+
+	c.lines = append(c.lines, line)
+	if !match || len(c.lines) >= c.maxLines {
+		return c.Flush(), nil
+	}
+	return nil, nil
+}
+
+// Buffered returns the number of lines held for the next Event.
+func (c *MultilineCodec) Buffered() int {
+	return len(c.lines)
+}
+
+// Flush returns an Event made of the lines held by the codec. If no lines
+// are held, nil is returned.
+func (c *MultilineCodec) Flush() *event.Event {
+	if len(c.lines) == 0 {
+		return nil
+	}
+
+	e := event.New(strings.Join(c.lines, "\n"))
+	if len(c.lines) > 1 {
+		e.AddTag("multiline")
+	}
+	c.lines = c.lines[:0]
+	return e
+}
+
+// FlushInterval returns how long lines may wait for the rest of their Event
+// before they should be flushed. Zero means lines wait indefinitely.
+func (c *MultilineCodec) FlushInterval() time.Duration {
+	return c.timeout
+}
@@ -0,0 +1,107 @@
+package codecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func decodeLines(t *testing.T, c *MultilineCodec, lines []string) []string {
+	var messages []string
+	for _, line := range lines {
+		e, err := c.Decode([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e != nil {
+			messages = append(messages, e.GetMessage())
+		}
+	}
+	if e := c.Flush(); e != nil {
+		messages = append(messages, e.GetMessage())
+	}
+	return messages
+}
+
+func TestMultilineCodec(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  map[string]interface{}
+		lines    []string
+		expected []string
+	}{
+		{
+			name: "previous",
+			options: map[string]interface{}{
+				"pattern": `^\s+at `,
+				"what":    "previous",
+			},
+			lines: []string{
+				"Exception in thread main",
+				"    at com.example.Main.run(Main.java:10)",
+				"    at com.example.Main.main(Main.java:5)",
+				"Done",
+			},
+			expected: []string{
+				"Exception in thread main\n    at com.example.Main.run(Main.java:10)\n    at com.example.Main.main(Main.java:5)",
+				"Done",
+			},
+		},
+		{
+			name: "next",
+			options: map[string]interface{}{
+				"pattern": `\\$`,
+				"what":    "next",
+			},
+			lines:    []string{`one \`, `two \`, "three", "four"},
+			expected: []string{"one \\\ntwo \\\nthree", "four"},
+		},
+		{
+			name: "negate",
+			options: map[string]interface{}{
+				"pattern": `^\[`,
+				"negate":  true,
+				"what":    "previous",
+			},
+			lines:    []string{"[1] start", "more", "[2] start"},
+			expected: []string{"[1] start\nmore", "[2] start"},
+		},
+		{
+			name: "max lines",
+			options: map[string]interface{}{
+				"pattern":   `^\s`,
+				"what":      "previous",
+				"max_lines": 2,
+			},
+			lines:    []string{"a", " b", " c", " d"},
+			expected: []string{"a\n b", " c\n d"},
+		},
+	}
+
+	for _, test := range tests {
+		c, err := NewMultilineCodec(test.options)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		messages := decodeLines(t, c, test.lines)
+		if !reflect.DeepEqual(messages, test.expected) {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, messages)
+		}
+	}
+}
+
+func TestMultilineCodecConfig(t *testing.T) {
+	bad := []map[string]interface{}{
+		{"what": "previous"},
+		{"pattern": "^\\s"},
+		{"pattern": "^\\s", "what": "sideways"},
+		{"pattern": "%{NOTAPATTERN}", "what": "next"},
+		{"pattern": "^\\s", "what": "next", "max_lines": 0},
+	}
+
+	for _, options := range bad {
+		if _, err := NewMultilineCodec(options); err == nil {
+			t.Errorf("Expected error for options %v", options)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package codecs
+
+import (
+	"errors"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// The PlainCodec encodes an event as its message and decodes data as the
+// message of a new event.
+//
+// Options:
+//
+//	format => "%{host}: %{message}"   (encode this text instead of the message,
+//	                                   %{field} is replaced with the field's value)
+type PlainCodec struct {
+	format string
+}
+
+func init() {
+	register("plain", newPlainCodec)
+}
+
+func newPlainCodec(options map[string]interface{}) (Codec, error) {
+	format, err := formatOption(options)
+	if err != nil {
+		return nil, err
+	}
+	return &PlainCodec{format: format}, nil
+}
+
+// formatOption returns the format option, or an empty string if it isn't set.
+func formatOption(options map[string]interface{}) (string, error) {
+	s, exists := options["format"]
+	if !exists {
+		return "", nil
+	}
+	format, ok := s.(string)
+	if !ok {
+		return "", errors.New("format must be a string")
+	}
+	return format, nil
+}
+
+// Encode Event as its message or formatted text.
+func (c *PlainCodec) Encode(e *event.Event) []byte {
+	if c.format == "" {
+		return []byte(e.GetMessage())
+	}
+	return []byte(e.Sprintf(c.format))
+}
+
+// Decode data as the message of an Event.
+func (c *PlainCodec) Decode(data []byte) (*event.Event, error) {
+	return event.New(string(data)), nil
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// Config is the main configuration of Spartan. It contains global settings
+// that apply to all pipelines. The configuration file is made of sections:
+//
+//	pipeline {
+//		filter_batch_size => 125
+//		output_batch_size => 125
+//		filter_workers => 2
+//		preserve_order => false
+//		flush_interval => "1s"
+//	}
+//
+//	paths {
+//		patterns => ["/etc/spartan/patterns"]
+//		data => "/var/lib/spartan"
+//	}
+//
+//	logging {
+//		level => "info"
+//	}
+type Config struct {
+	Pipeline *PipelineConfig
+	Paths    *PathsConfig
+	Logging  *LoggingConfig
+}
+
+// PipelineConfig contains settings for the filter and output controllers.
+type PipelineConfig struct {
+	// FilterBatchSize is the number of events collected before running filters.
+	FilterBatchSize int
+
+	// OutputBatchSize is the number of events collected before running outputs.
+	OutputBatchSize int
+
+	// FilterWorkers is the number of filter pipelines run in parallel.
+	FilterWorkers int
+
+	// PreserveOrder keeps events in input order when using multiple filter workers.
+	PreserveOrder bool
+
+	// FlushInterval is the maximum time a partial batch will wait before
+	// being processed.
+	FlushInterval time.Duration
+}
+
+// PathsConfig contains filesystem locations used by Spartan.
+type PathsConfig struct {
+	// Patterns are extra grok pattern files or directories to load.
+	Patterns []string
+
+	// Data is a directory where modules can persist state.
+	Data string
+}
+
+// LoggingConfig contains settings for application logging.
+type LoggingConfig struct {
+	Level utils.LogLevel
+}
+
+// An ErrorList is a collection of configuration errors.
+type ErrorList []error
+
+func (e ErrorList) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// A PathError is a configured path that can't be used.
+type PathError struct {
+	// Setting is the section and name of the setting, such as paths.data.
+	Setting string
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Setting, e.Err)
+}
+
+// Default returns a Config with the default settings.
+func Default() *Config {
+	return &Config{
+		Pipeline: &PipelineConfig{
+			FilterBatchSize: 10,
+			OutputBatchSize: 10,
+			FilterWorkers:   1,
+			FlushInterval:   time.Second,
+		},
+		Paths: &PathsConfig{
+			Patterns: []string{},
+			Data:     "data",
+		},
+		Logging: &LoggingConfig{
+			Level: utils.LogLevelInfo,
+		},
+	}
+}
+
+// Load reads the configuration file at path. Settings not in the file
+// keep their default value. If the file has any errors, an ErrorList
+// is returned with all errors found. Errors include the setting's location.
+func Load(path string) (*Config, error) {
+	sections, err := parser.ParseSettingsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Default()
+	var errs ErrorList
+
+	for _, section := range sections {
+		switch section.Name {
+		case "pipeline":
+			errs = append(errs, c.Pipeline.set(section)...)
+		case "paths":
+			errs = append(errs, c.Paths.set(section)...)
+		case "logging":
+			errs = append(errs, c.Logging.set(section)...)
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown section %s", section.Position(), section.Name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return c, nil
+}
+
+// Validate checks the settings against the system, for example that
+// configured paths exist. All problems found are returned, problems with
+// paths are PathErrors.
+func (c *Config) Validate() []error {
+	var errs []error
+
+	for _, path := range c.Paths.Patterns {
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, &PathError{Setting: "paths.patterns", Err: err})
+		}
+	}
+
+	if stat, err := os.Stat(c.Paths.Data); err == nil && !stat.IsDir() {
+		errs = append(errs, &PathError{Setting: "paths.data", Err: fmt.Errorf("%s is not a directory", c.Paths.Data)})
+	}
+
+	return errs
+}
+
+func (c *PipelineConfig) set(s *parser.SettingsSection) []error {
+	var errs []error
+
+	for _, key := range s.Options.Keys() {
+		val := s.Options.Get(key)
+		var err error
+		switch key {
+		case "filter_batch_size":
+			c.FilterBatchSize, err = positiveInt(val)
+		case "output_batch_size":
+			c.OutputBatchSize, err = positiveInt(val)
+		case "filter_workers":
+			c.FilterWorkers, err = positiveInt(val)
+		case "preserve_order":
+			c.PreserveOrder, err = boolean(val)
+		case "flush_interval":
+			c.FlushInterval, err = duration(val)
+		default:
+			err = errUnknownSetting
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: pipeline.%s: %v", s.KeyPosition(key), key, err))
+		}
+	}
+	return errs
+}
+
+func (c *PathsConfig) set(s *parser.SettingsSection) []error {
+	var errs []error
+
+	for _, key := range s.Options.Keys() {
+		val := s.Options.Get(key)
+		var err error
+		switch key {
+		case "patterns":
+			c.Patterns, err = stringSlice(val)
+		case "data":
+			c.Data, err = str(val)
+		default:
+			err = errUnknownSetting
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: paths.%s: %v", s.KeyPosition(key), key, err))
+		}
+	}
+	return errs
+}
+
+func (c *LoggingConfig) set(s *parser.SettingsSection) []error {
+	var errs []error
+
+	for _, key := range s.Options.Keys() {
+		val := s.Options.Get(key)
+		var err error
+		switch key {
+		case "level":
+			var level string
+			level, err = str(val)
+			if err == nil {
+				c.Level, err = utils.ParseLogLevel(level)
+			}
+		default:
+			err = errUnknownSetting
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: logging.%s: %v", s.KeyPosition(key), key, err))
+		}
+	}
+	return errs
+}
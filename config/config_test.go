@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/utils"
+)
+
+func TestLoad(t *testing.T) {
+	c, err := Load("./testdata/spartan.conf")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	expected := Default()
+	expected.Pipeline.FilterBatchSize = 125
+	expected.Pipeline.FilterWorkers = 4
+	expected.Pipeline.FlushInterval = 500 * time.Millisecond
+	expected.Paths.Patterns = []string{"/etc/spartan/patterns"}
+	expected.Logging.Level = utils.LogLevelDebug
+
+	if !reflect.DeepEqual(expected, c) {
+		t.Fatalf("Incorrect config. Expected %#v,\n\ngot %#v", expected, c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load("./testdata/bad.conf")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	errs, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("Expected an ErrorList, got %#v", err)
+	}
+
+	// Each bad setting and the unknown section is reported
+	if len(errs) != 5 {
+		t.Fatalf("Incorrect error count. Expected 5, got %d:\n%s", len(errs), err.Error())
+	}
+
+	// Errors give the location of the setting
+	for _, prefix := range []string{
+		"./testdata/bad.conf:2:5: pipeline.filter_batch_size:",
+		"./testdata/bad.conf:8:5: logging.level:",
+		"./testdata/bad.conf:11:1: unknown section other",
+	} {
+		found := false
+		for _, err := range errs {
+			found = found || strings.HasPrefix(err.Error(), prefix)
+		}
+		if !found {
+			t.Errorf("Expected an error starting with %q, got:\n%s", prefix, err.Error())
+		}
+	}
+}
@@ -19,13 +19,13 @@ type Lexer struct {
 
 func New(reader io.Reader) *Lexer {
 	l := &Lexer{
-		input:  bufio.NewReader(reader),
-		line:   1,
-		column: 1,
+		input: bufio.NewReader(reader),
+		line:  1,
 	}
 	// Populate both current and peek char
 	l.readChar()
 	l.readChar()
+	l.column = 1 // Populating advanced the column, reset to the first character
 	return l
 }
 
@@ -34,6 +34,13 @@ func NewString(input string) *Lexer {
 }
 
 func (l *Lexer) readChar() {
+	// line and column track the position of curCh
+	if l.curCh == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	l.curCh = l.peekCh
 
 	var err error
@@ -44,89 +51,90 @@ func (l *Lexer) readChar() {
 
 	if l.curCh == '\r' {
 		l.readChar()
-	} else if l.curCh == '\n' {
-		l.line++
-		l.column = 0
 	}
-	l.column++
 }
 
 func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.devourWhitespace()
+	line, col := l.line, l.column // Tokens are positioned at their first character
 
 	switch l.curCh {
 	// Operators
 	case '+':
-		tok = token.NewSimpleToken(token.PLUS, l.line, l.column)
+		tok = token.NewSimpleToken(token.PLUS, line, col)
 	case '-':
-		tok = token.NewSimpleToken(token.MINUS, l.line, l.column)
+		tok = token.NewSimpleToken(token.MINUS, line, col)
 	case '*':
-		tok = token.NewSimpleToken(token.ASTERISK, l.line, l.column)
+		tok = token.NewSimpleToken(token.ASTERISK, line, col)
 	case '/':
 		if l.peekChar() == '/' {
 			l.readChar()
-			tok = token.NewToken(token.COMMENT, l.readSingleLineComment(), l.line, l.column)
+			tok = token.NewToken(token.COMMENT, l.readSingleLineComment(), line, col)
 		} else if l.peekChar() == '*' {
 			l.readChar()
-			tok = token.NewToken(token.COMMENT, l.readMultiLineComment(), l.line, l.column)
+			tok = token.NewToken(token.COMMENT, l.readMultiLineComment(), line, col)
 		} else {
-			tok = token.NewSimpleToken(token.SLASH, l.line, l.column)
+			tok = token.NewSimpleToken(token.SLASH, line, col)
 		}
 	case '!':
 		if l.peekChar() == '=' {
 			l.readChar()
-			tok = token.NewSimpleToken(token.NOTEQ, l.line, l.column)
+			tok = token.NewSimpleToken(token.NOTEQ, line, col)
 		} else {
-			tok = token.NewSimpleToken(token.BANG, l.line, l.column)
+			tok = token.NewSimpleToken(token.BANG, line, col)
 		}
 
 	// Equality
 	case '=':
 		if l.peekChar() == '=' {
 			l.readChar()
-			tok = token.NewSimpleToken(token.EQ, l.line, l.column)
+			tok = token.NewSimpleToken(token.EQ, line, col)
 		} else if l.peekChar() == '>' {
 			l.readChar()
-			tok = token.NewSimpleToken(token.ASSIGN, l.line, l.column)
+			tok = token.NewSimpleToken(token.ASSIGN, line, col)
 		} else {
-			tok = token.NewSimpleToken(token.ILLEGAL, l.line, l.column)
+			tok = token.NewSimpleToken(token.ILLEGAL, line, col)
 		}
 	case '<':
-		tok = token.NewSimpleToken(token.LT, l.line, l.column)
+		tok = token.NewSimpleToken(token.LT, line, col)
 	case '>':
-		tok = token.NewSimpleToken(token.GT, l.line, l.column)
+		tok = token.NewSimpleToken(token.GT, line, col)
 
 	// Control characters
 	case ',':
-		tok = token.NewSimpleToken(token.COMMA, l.line, l.column)
+		tok = token.NewSimpleToken(token.COMMA, line, col)
 
 	// Groupings
 	case '{':
-		tok = token.NewSimpleToken(token.LBRACE, l.line, l.column)
+		tok = token.NewSimpleToken(token.LBRACE, line, col)
 	case '}':
-		tok = token.NewSimpleToken(token.RBRACE, l.line, l.column)
+		tok = token.NewSimpleToken(token.RBRACE, line, col)
 	case '[':
-		tok = token.NewSimpleToken(token.LSQUARE, l.line, l.column)
+		tok = token.NewSimpleToken(token.LSQUARE, line, col)
 	case ']':
-		tok = token.NewSimpleToken(token.RSQUARE, l.line, l.column)
+		tok = token.NewSimpleToken(token.RSQUARE, line, col)
+	case '(':
+		tok = token.NewSimpleToken(token.LPAREN, line, col)
+	case ')':
+		tok = token.NewSimpleToken(token.RPAREN, line, col)
 
 	case '"':
-		tok = token.NewToken(token.STRING, l.readString(), l.line, l.column)
+		tok = token.NewToken(token.STRING, l.readString(), line, col)
 	case '#':
-		tok = token.NewToken(token.COMMENT, l.readSingleLineComment(), l.line, l.column)
+		tok = token.NewToken(token.COMMENT, l.readSingleLineComment(), line, col)
 	case 0:
-		tok = token.NewSimpleToken(token.EOF, l.line, l.column)
+		tok = token.NewSimpleToken(token.EOF, line, col)
 
 	default:
 		if isLetter(l.curCh) {
 			lit := l.readIdentifier()
 			tokType := token.LookupIdent(lit)
 			if token.IsKeyword(tokType) { // No need to save the literal keyword
-				tok = token.NewSimpleToken(tokType, l.line, l.column)
+				tok = token.NewSimpleToken(tokType, line, col)
 			} else {
-				tok = token.NewToken(tokType, lit, l.line, l.column)
+				tok = token.NewToken(tokType, lit, line, col)
 			}
 			return tok
 		} else if isDigit(l.curCh) {
@@ -134,7 +142,7 @@ func (l *Lexer) NextToken() token.Token {
 			return tok
 		}
 
-		tok = token.NewSimpleToken(token.ILLEGAL, l.line, l.column)
+		tok = token.NewSimpleToken(token.ILLEGAL, line, col)
 	}
 
 	l.readChar()
@@ -147,7 +155,7 @@ func (l *Lexer) peekChar() byte {
 
 func (l *Lexer) readIdentifier() string {
 	var ident bytes.Buffer
-	for isLetter(l.curCh) {
+	for isLetter(l.curCh) || isIdentDigit(l.curCh) {
 		ident.WriteByte(l.curCh)
 		l.readChar()
 	}
@@ -218,7 +226,13 @@ func (l *Lexer) devourWhitespace() {
 }
 
 func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch == '@'
+}
+
+// isIdentDigit checks for digits that may appear in an identifier
+// after the first character.
+func isIdentDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
 }
 
 func isDigit(ch byte) bool {
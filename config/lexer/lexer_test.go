@@ -182,6 +182,34 @@ multiline comment */`,
 			token.NewSimpleToken(token.RBRACE, 0, 0),
 		},
 	},
+	{
+		input: `if [type] == "dns" and ([port1] > 53 or "tag" not in [tags]) { }`,
+		output: []token.Token{
+			token.NewSimpleToken(token.IF, 0, 0),
+			token.NewSimpleToken(token.LSQUARE, 0, 0),
+			token.NewToken(token.IDENT, "type", 0, 0),
+			token.NewSimpleToken(token.RSQUARE, 0, 0),
+			token.NewSimpleToken(token.EQ, 0, 0),
+			token.NewToken(token.STRING, "dns", 0, 0),
+			token.NewSimpleToken(token.AND, 0, 0),
+			token.NewSimpleToken(token.LPAREN, 0, 0),
+			token.NewSimpleToken(token.LSQUARE, 0, 0),
+			token.NewToken(token.IDENT, "port1", 0, 0),
+			token.NewSimpleToken(token.RSQUARE, 0, 0),
+			token.NewSimpleToken(token.GT, 0, 0),
+			token.NewToken(token.INT, "53", 0, 0),
+			token.NewSimpleToken(token.OR, 0, 0),
+			token.NewToken(token.STRING, "tag", 0, 0),
+			token.NewSimpleToken(token.NOT, 0, 0),
+			token.NewSimpleToken(token.IN, 0, 0),
+			token.NewSimpleToken(token.LSQUARE, 0, 0),
+			token.NewToken(token.IDENT, "tags", 0, 0),
+			token.NewSimpleToken(token.RSQUARE, 0, 0),
+			token.NewSimpleToken(token.RPAREN, 0, 0),
+			token.NewSimpleToken(token.LBRACE, 0, 0),
+			token.NewSimpleToken(token.RBRACE, 0, 0),
+		},
+	},
 }
 
 func TestLexer(t *testing.T) {
@@ -219,3 +247,31 @@ func TestLexer(t *testing.T) {
 		}
 	}
 }
+
+func TestLexerPositions(t *testing.T) {
+	l := NewString(`input {
+	file {
+		path => "/tmp/test"
+	}
+}`)
+
+	expected := []token.Token{
+		token.NewSimpleToken(token.INPUT, 1, 1),
+		token.NewSimpleToken(token.LBRACE, 1, 7),
+		token.NewToken(token.IDENT, "file", 2, 2),
+		token.NewSimpleToken(token.LBRACE, 2, 7),
+		token.NewToken(token.IDENT, "path", 3, 3),
+		token.NewSimpleToken(token.ASSIGN, 3, 8),
+		token.NewToken(token.STRING, "/tmp/test", 3, 11),
+		token.NewSimpleToken(token.RBRACE, 4, 2),
+		token.NewSimpleToken(token.RBRACE, 5, 1),
+	}
+
+	for i, tok := range expected {
+		next := l.NextToken()
+		if next.Line != tok.Line || next.Column != tok.Column {
+			t.Errorf("Token %d (%s) at wrong position. Expected %d:%d, got %d:%d",
+				i+1, tok.Type, tok.Line, tok.Column, next.Line, next.Column)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package parser
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lfkeitel/spartan/config/token"
+	"github.com/lfkeitel/spartan/event"
+)
+
+// A Condition is the parsed expression of an if statement. Conditions
+// are evaluated against a single Event.
+type Condition interface {
+	// Evaluate returns if the Event satisfies the condition.
+	Evaluate(e *event.Event) bool
+}
+
+// A value is an operand in a condition. It may be a literal or
+// a reference to an Event field.
+type value interface {
+	resolve(e *event.Event) interface{}
+}
+
+// fieldValue references an Event field. Nested fields are stored
+// in bracket notation, i.e. [a][b].
+type fieldValue string
+
+func (v fieldValue) resolve(e *event.Event) interface{} {
+	return e.Get(string(v))
+}
+
+// literalValue is a string, number, boolean, or array written in the configuration.
+type literalValue struct {
+	val interface{}
+}
+
+func (v literalValue) resolve(e *event.Event) interface{} {
+	return v.val
+}
+
+type andCondition struct {
+	left, right Condition
+}
+
+func (c *andCondition) Evaluate(e *event.Event) bool {
+	return c.left.Evaluate(e) && c.right.Evaluate(e)
+}
+
+type orCondition struct {
+	left, right Condition
+}
+
+func (c *orCondition) Evaluate(e *event.Event) bool {
+	return c.left.Evaluate(e) || c.right.Evaluate(e)
+}
+
+type notCondition struct {
+	cond Condition
+}
+
+func (c *notCondition) Evaluate(e *event.Event) bool {
+	return !c.cond.Evaluate(e)
+}
+
+// valueCondition is a lone operand such as "if [field] {}". It's true
+// if the value exists and isn't false or an empty string.
+type valueCondition struct {
+	val value
+}
+
+func (c *valueCondition) Evaluate(e *event.Event) bool {
+	return isTruthy(c.val.resolve(e))
+}
+
+type compareCondition struct {
+	left     value
+	operator token.Type
+	right    value
+}
+
+func (c *compareCondition) Evaluate(e *event.Event) bool {
+	left := c.left.resolve(e)
+	right := c.right.resolve(e)
+
+	switch c.operator {
+	case token.EQ:
+		return isEqual(left, right)
+	case token.NOTEQ:
+		return !isEqual(left, right)
+	case token.LT:
+		cmp, ok := compare(left, right)
+		return ok && cmp < 0
+	case token.GT:
+		cmp, ok := compare(left, right)
+		return ok && cmp > 0
+	case token.IN:
+		return isIn(left, right)
+	case token.NOT:
+		return !isIn(left, right)
+	}
+	return false
+}
+
+func isTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+// toFloat converts numbers and numeric strings to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+func isEqual(left, right interface{}) bool {
+	if isNumber(left) || isNumber(right) {
+		l, lok := toFloat(left)
+		r, rok := toFloat(right)
+		if lok && rok {
+			return l == r
+		}
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// compare returns -1, 0, or 1 if left is less than, equal to, or greater than right.
+// Numbers and numeric strings are compared numerically, other strings lexicographically.
+// The returned bool is false if the values can't be compared.
+func compare(left, right interface{}) (int, bool) {
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	if lok && rok {
+		switch {
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	if lok && rok {
+		return strings.Compare(ls, rs), true
+	}
+	return 0, false
+}
+
+// isIn checks if needle is an element of haystack when haystack is an array,
+// or a substring of haystack when both are strings.
+func isIn(needle, haystack interface{}) bool {
+	if s, ok := haystack.(string); ok {
+		n, ok := needle.(string)
+		return ok && strings.Contains(s, n)
+	}
+
+	h := reflect.ValueOf(haystack)
+	if h.Kind() != reflect.Slice {
+		return false
+	}
+
+	for i := 0; i < h.Len(); i++ {
+		if isEqual(needle, h.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCondition parses a full boolean expression.
+func (p *parser) parseCondition() (Condition, error) {
+	return p.parseOrCondition()
+}
+
+func (p *parser) parseOrCondition() (Condition, error) {
+	left, err := p.parseAndCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.curTok.Type == token.OR {
+		p.nextToken()
+		right, err := p.parseAndCondition()
+		if err != nil {
+			return nil, err
+		}
+		left = &orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAndCondition() (Condition, error) {
+	left, err := p.parseNotCondition()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.curTok.Type == token.AND {
+		p.nextToken()
+		right, err := p.parseNotCondition()
+		if err != nil {
+			return nil, err
+		}
+		left = &andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNotCondition() (Condition, error) {
+	if p.curTok.Type == token.NOT || p.curTok.Type == token.BANG {
+		p.nextToken()
+		cond, err := p.parseNotCondition()
+		if err != nil {
+			return nil, err
+		}
+		return &notCondition{cond: cond}, nil
+	}
+
+	if p.curTok.Type == token.LPAREN {
+		p.nextToken()
+		cond, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		if p.curTok.Type != token.RPAREN {
+			return nil, p.tokenError(token.RPAREN)
+		}
+		p.nextToken()
+		return cond, nil
+	}
+
+	return p.parseCompareCondition()
+}
+
+func (p *parser) parseCompareCondition() (Condition, error) {
+	left, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := p.curTok.Type
+	switch operator {
+	case token.EQ, token.NOTEQ, token.LT, token.GT, token.IN:
+		p.nextToken()
+	case token.NOT:
+		// "not in" is stored with the NOT operator
+		if p.peekTok.Type != token.IN {
+			return nil, p.errorf(p.peekTok, "expected in after not, got %s", p.peekTok.Type.String())
+		}
+		p.nextToken()
+		p.nextToken()
+	default:
+		return &valueCondition{val: left}, nil
+	}
+
+	right, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &compareCondition{
+		left:     left,
+		operator: operator,
+		right:    right,
+	}, nil
+}
+
+func (p *parser) parseValue() (value, error) {
+	val := p.curTok
+
+	switch val.Type {
+	case token.STRING:
+		p.nextToken()
+		return literalValue{val: val.Literal}, nil
+	case token.INT:
+		valInt, err := strconv.Atoi(val.Literal)
+		if err != nil {
+			return nil, p.errorf(val, "invalid integer %s", val.Literal)
+		}
+		p.nextToken()
+		return literalValue{val: valInt}, nil
+	case token.FLOAT:
+		valFloat, err := strconv.ParseFloat(val.Literal, 64)
+		if err != nil {
+			return nil, p.errorf(val, "invalid floating point number %s", val.Literal)
+		}
+		p.nextToken()
+		return literalValue{val: valFloat}, nil
+	case token.TRUE:
+		p.nextToken()
+		return literalValue{val: true}, nil
+	case token.FALSE:
+		p.nextToken()
+		return literalValue{val: false}, nil
+	case token.LSQUARE:
+		if p.peekTok.Type == token.IDENT {
+			return p.parseFieldReference()
+		}
+		array, err := p.parseArray()
+		if err != nil {
+			return nil, err
+		}
+		return literalValue{val: array}, nil
+	}
+
+	return nil, p.tokenError(token.LSQUARE, token.STRING, token.INT, token.FLOAT)
+}
+
+// parseFieldReference parses a field reference such as [field] or [a][b].
+func (p *parser) parseFieldReference() (value, error) {
+	parts := make([]string, 0, 2)
+
+	for p.curTok.Type == token.LSQUARE && p.peekTok.Type == token.IDENT {
+		p.nextToken()
+		parts = append(parts, p.curTok.Literal)
+		p.nextToken()
+
+		if p.curTok.Type != token.RSQUARE {
+			return nil, p.tokenError(token.RSQUARE)
+		}
+		p.nextToken()
+	}
+
+	if len(parts) == 1 {
+		return fieldValue(parts[0]), nil
+	}
+	return fieldValue("[" + strings.Join(parts, "][") + "]"), nil
+}
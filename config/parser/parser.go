@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -32,6 +35,16 @@ type ParsedFile struct {
 type InputDef struct {
 	Module  string
 	Options *utils.InterfaceMap
+
+	// File, Line, and Column are the source location where the module is defined.
+	// File is empty if the configuration wasn't read from a file.
+	File         string
+	Line, Column int
+}
+
+// Position returns the location of the definition in the configuration.
+func (d *InputDef) Position() string {
+	return position(d.File, d.Line, d.Column)
 }
 
 // PipelineDef defines a pipeline object (Filter/Output). It contains
@@ -40,23 +53,98 @@ type PipelineDef struct {
 	Module  string
 	Options *utils.InterfaceMap
 
+	// File, Line, and Column are the source location where the module is defined.
+	// File is empty if the configuration wasn't read from a file.
+	File         string
+	Line, Column int
+
+	// Condition is the expression of an if statement. It's nil for
+	// normal modules. If statements use the module name "if".
+	Condition Condition
+
 	// Connections is a slice of index numbers corresponding to the index of
 	// a pipeline definition in the parent ParsedFile struct.
 	//
 	// Length 0 -> end of pipeline
 	// Length 1 -> normal next connection
 	// Length 3 -> if statement connections
-	//	Connections[0] -> true (inside if body)
-	//	Connections[1] -> else (inside else body if present), nil if no else
-	//	Connections[2] -> next object (after closing brace of if/else body)
+	//	Connections[0] -> true (inside if body), -1 if the body is empty
+	//	Connections[1] -> else (inside else body if present), -1 if no else
+	//	Connections[2] -> next object (after closing brace of if/else body), -1 if none
+	//
+	// The last definition in the body of an if or else block has no connections,
+	// execution continues with the if statement's next object.
 	Connections []int
 }
 
+// Position returns the location of the definition in the configuration.
+func (d *PipelineDef) Position() string {
+	return position(d.File, d.Line, d.Column)
+}
+
+// A ModuleDef is a map value naming a module with options, such as the codec
+// option `codec => json { pretty => true }`. The options map may be omitted.
+type ModuleDef struct {
+	Module  string
+	Options *utils.InterfaceMap
+}
+
+// ModuleOption reads the value of an option naming a module, either a module
+// name such as "json" or a definition with options such as `json { pretty => true }`.
+// False is returned if val is neither.
+func ModuleOption(val interface{}) (string, map[string]interface{}, bool) {
+	switch val := val.(type) {
+	case string:
+		return val, nil, true
+	case *ModuleDef:
+		return val.Module, val.Options.Map(), true
+	}
+	return "", nil, false
+}
+
+func position(file string, line, column int) string {
+	if file == "" {
+		return fmt.Sprintf("line %d, column %d", line, column)
+	}
+	return fmt.Sprintf("%s:%d:%d", file, line, column)
+}
+
+// ErrNoFiles is returned by ParseGlob when the pattern doesn't match any files.
+var ErrNoFiles = errors.New("no configuration files found")
+
 // ParseGlob will parse a collection of files in lexicographical order based
 // on the supplied pattern. The files will effectivly be concatinated together
-// as a single configuration.
+// as a single configuration. Directories matching the pattern are skipped.
 func ParseGlob(pattern string) (*ParsedFile, error) {
-	return nil, utils.ErrNotImplemented
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	parsed := &ParsedFile{}
+	fileCount := 0
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		file, err := ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed.merge(file)
+		fileCount++
+	}
+
+	if fileCount == 0 {
+		return nil, ErrNoFiles
+	}
+	return parsed, nil
 }
 
 // ParseFile parses a single file without any globing.
@@ -66,7 +154,10 @@ func ParseFile(path string) (*ParsedFile, error) {
 		return nil, err
 	}
 	defer file.Close()
-	return Parse(file)
+
+	p := newParser(lexer.New(file))
+	p.filename = path
+	return p.parse()
 }
 
 // ParseString parses the given string as a pipeline configuration.
@@ -81,7 +172,8 @@ func Parse(r io.Reader) (*ParsedFile, error) {
 }
 
 type parser struct {
-	file *ParsedFile
+	file     *ParsedFile
+	filename string
 
 	curTok  token.Token
 	peekTok token.Token
@@ -102,6 +194,9 @@ func newParser(l *lexer.Lexer) *parser {
 func (p *parser) nextToken() token.Token {
 	p.curTok = p.peekTok
 	p.peekTok = p.lexer.NextToken()
+	for p.peekTok.Type == token.COMMENT { // Comments are ignored by the parser
+		p.peekTok = p.lexer.NextToken()
+	}
 	return p.curTok
 }
 
@@ -113,13 +208,19 @@ parseLoop:
 		switch p.curTok.Type {
 		case token.INPUT:
 			p.nextToken()
-			p.file.Inputs, err = p.parseInputs()
+			var inputs []*InputDef
+			inputs, err = p.parseInputs()
+			p.file.Inputs = append(p.file.Inputs, inputs...)
 		case token.FILTER:
 			p.nextToken()
-			p.file.Filters, err = p.parsePipelineDefs()
+			var filters []*PipelineDef
+			filters, err = p.parsePipelineDefs()
+			p.file.Filters = appendPipeline(p.file.Filters, filters)
 		case token.OUTPUT:
 			p.nextToken()
-			p.file.Outputs, err = p.parsePipelineDefs()
+			var outputs []*PipelineDef
+			outputs, err = p.parsePipelineDefs()
+			p.file.Outputs = appendPipeline(p.file.Outputs, outputs)
 		case token.EOF:
 			break parseLoop
 		default:
@@ -152,6 +253,7 @@ func (p *parser) parseInputs() ([]*InputDef, error) {
 		}
 
 		modName := p.curTok.Literal
+		modTok := p.curTok
 		p.nextToken()
 
 		options, err := p.parseMap()
@@ -162,6 +264,9 @@ func (p *parser) parseInputs() ([]*InputDef, error) {
 		inputs = append(inputs, &InputDef{
 			Module:  modName,
 			Options: options,
+			File:    p.filename,
+			Line:    modTok.Line,
+			Column:  modTok.Column,
 		})
 	}
 
@@ -169,57 +274,199 @@ func (p *parser) parseInputs() ([]*InputDef, error) {
 	return inputs, nil
 }
 
+// merge appends the definitions from other to the end of f. The
+// other filter and output pipelines continue where f's pipelines end.
+func (f *ParsedFile) merge(other *ParsedFile) {
+	f.Inputs = append(f.Inputs, other.Inputs...)
+	f.Filters = appendPipeline(f.Filters, other.Filters)
+	f.Outputs = appendPipeline(f.Outputs, other.Outputs)
+}
+
+// appendPipeline joins two pipelines so the root of b is run after the end
+// of a. Connection indexes in b are adjusted to their new position.
+func appendPipeline(a, b []*PipelineDef) []*PipelineDef {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+
+	offset := len(a)
+	for _, def := range b {
+		for i, c := range def.Connections {
+			if c > -1 {
+				def.Connections[i] = c + offset
+			}
+		}
+	}
+
+	connectPipelineDef(pipelineTail(a), offset)
+	return append(a, b...)
+}
+
+// pipelineTail returns the last top level definition of a pipeline.
+func pipelineTail(defs []*PipelineDef) *PipelineDef {
+	def := defs[0]
+	for {
+		next := -1
+		switch len(def.Connections) {
+		case 1:
+			next = def.Connections[0]
+		case 3:
+			next = def.Connections[2]
+		}
+
+		if next < 0 {
+			return def
+		}
+		def = defs[next]
+	}
+}
+
 func (p *parser) parsePipelineDefs() ([]*PipelineDef, error) {
+	modules := make([]*PipelineDef, 0, 5)
+	if _, err := p.parsePipelineBlock(&modules); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// parsePipelineBlock parses a brace enclosed block of modules and if statements
+// appending them to modules. Modules in the block are connected to each other in
+// order. The index of the first definition in the block is returned, or -1 if the
+// block is empty.
+func (p *parser) parsePipelineBlock(modules *[]*PipelineDef) (int, error) {
 	if p.curTok.Type != token.LBRACE {
-		return nil, p.tokenError(token.LBRACE)
+		return 0, p.tokenError(token.LBRACE)
 	}
 
 	p.nextToken()
 
-	modules := make([]*PipelineDef, 0, 5)
+	first := -1
+	prev := -1
 	for {
 		if p.curTok.Type == token.RBRACE {
 			break
 		}
 
-		if p.curTok.Type != token.IDENT {
-			return nil, p.tokenError(token.IDENT)
-		}
+		var index int
+		var err error
 
-		modName := p.curTok.Literal
-		p.nextToken()
+		switch p.curTok.Type {
+		case token.IDENT:
+			index, err = p.parsePipelineModule(modules)
+		case token.IF:
+			index, err = p.parseIfStatement(modules)
+		default:
+			err = p.tokenError(token.IDENT, token.IF)
+		}
 
-		options, err := p.parseMap()
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
 
-		modules = append(modules, &PipelineDef{
-			Module:  modName,
-			Options: options,
-		})
+		if first == -1 {
+			first = index
+		}
+		if prev > -1 {
+			connectPipelineDef((*modules)[prev], index)
+		}
+		prev = index
 	}
 
-	lastIndex := len(modules) - 1
-	for i, mod := range modules {
-		if i == lastIndex {
-			break
+	p.nextToken() // Consume closing }
+	return first, nil
+}
+
+// connectPipelineDef sets the next module of def to the index next. If statements
+// are connected using their third connection.
+func connectPipelineDef(def *PipelineDef, next int) {
+	if len(def.Connections) == 3 {
+		def.Connections[2] = next
+		return
+	}
+	def.Connections = []int{next}
+}
+
+func (p *parser) parsePipelineModule(modules *[]*PipelineDef) (int, error) {
+	modName := p.curTok.Literal
+	modTok := p.curTok
+	p.nextToken()
+
+	options, err := p.parseMap()
+	if err != nil {
+		return 0, err
+	}
+
+	*modules = append(*modules, &PipelineDef{
+		Module:  modName,
+		Options: options,
+		File:    p.filename,
+		Line:    modTok.Line,
+		Column:  modTok.Column,
+	})
+	return len(*modules) - 1, nil
+}
+
+// parseIfStatement parses an if statement with optional else and else if blocks.
+// The if definition is added to modules before the definitions of its bodies.
+func (p *parser) parseIfStatement(modules *[]*PipelineDef) (int, error) {
+	def := &PipelineDef{
+		Module: "if",
+		File:   p.filename,
+		Line:   p.curTok.Line,
+		Column: p.curTok.Column,
+	}
+	p.nextToken() // Consume if
+
+	cond, err := p.parseCondition()
+	if err != nil {
+		return 0, err
+	}
+	def.Condition = cond
+
+	*modules = append(*modules, def)
+	index := len(*modules) - 1
+
+	trueIndex, err := p.parsePipelineBlock(modules)
+	if err != nil {
+		return 0, err
+	}
+
+	elseIndex := -1
+	if p.curTok.Type == token.ELSE {
+		p.nextToken()
+		if p.curTok.Type == token.IF {
+			elseIndex, err = p.parseIfStatement(modules)
+		} else {
+			elseIndex, err = p.parsePipelineBlock(modules)
+		}
+		if err != nil {
+			return 0, err
 		}
-		mod.Connections = []int{i + 1}
 	}
 
-	p.nextToken() // Consume closing }
-	return modules, nil
+	def.Connections = []int{trueIndex, elseIndex, -1}
+	return index, nil
 }
 
 func (p *parser) parseMap() (*utils.InterfaceMap, error) {
+	m, _, err := p.parseMapKeys()
+	return m, err
+}
+
+// parseMapKeys parses a map and also returns the token of each key.
+func (p *parser) parseMapKeys() (*utils.InterfaceMap, map[string]token.Token, error) {
 	if p.curTok.Type != token.LBRACE {
-		return nil, p.tokenError(token.LBRACE)
+		return nil, nil, p.tokenError(token.LBRACE)
 	}
 
 	p.nextToken()
 
-	m := utils.NewInterfaceMap()
+	// Keys are set directly, a key such as "[a][b]" is not a field path
+	data := make(map[string]interface{})
+	keys := make(map[string]token.Token)
 mapLoop:
 	for {
 		switch p.curTok.Type {
@@ -232,10 +479,11 @@ mapLoop:
 			fallthrough
 		case token.STRING:
 			key := p.curTok.Literal
+			keys[key] = p.curTok
 			p.nextToken()
 
 			if p.curTok.Type != token.ASSIGN {
-				return nil, p.tokenError(token.ASSIGN)
+				return nil, nil, p.tokenError(token.ASSIGN)
 			}
 
 			p.nextToken()
@@ -243,45 +491,74 @@ mapLoop:
 
 			switch val.Type {
 			case token.STRING:
-				m.Set(key, val.Literal)
+				data[key] = val.Literal
 			case token.INT:
 				valInt, err := strconv.Atoi(val.Literal)
 				if err != nil {
-					return nil, fmt.Errorf("invalid integer %s", val.Literal)
+					return nil, nil, p.errorf(val, "invalid integer %s", val.Literal)
 				}
-				m.Set(key, valInt)
+				data[key] = valInt
 			case token.FLOAT:
 				valFloat, err := strconv.ParseFloat(val.Literal, 64)
 				if err != nil {
-					return nil, fmt.Errorf("invalid floating point number %s", val.Literal)
+					return nil, nil, p.errorf(val, "invalid floating point number %s", val.Literal)
 				}
-				m.Set(key, valFloat)
+				data[key] = valFloat
+			case token.TRUE:
+				data[key] = true
+			case token.FALSE:
+				data[key] = false
 			case token.LSQUARE:
 				array, err := p.parseArray()
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
-				m.Set(key, array)
+				data[key] = array
 				continue mapLoop
 			case token.LBRACE:
 				subMap, err := p.parseMap()
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
-				m.Set(key, subMap)
+				data[key] = subMap
+				continue mapLoop
+			case token.IDENT:
+				def, err := p.parseModuleDef()
+				if err != nil {
+					return nil, nil, err
+				}
+				data[key] = def
 				continue mapLoop
 			default:
-				return nil, p.tokenError(token.STRING, token.INT, token.FLOAT, token.LBRACE, token.LSQUARE)
+				return nil, nil, p.tokenError(token.STRING, token.INT, token.FLOAT, token.TRUE, token.FALSE, token.LBRACE, token.LSQUARE, token.IDENT)
 			}
 		default:
-			return nil, fmt.Errorf("map key must be a string: %s", p.curTok.Type)
+			return nil, nil, p.errorf(p.curTok, "map key must be a string, got %s", p.curTok.Type)
 		}
 
 		p.nextToken()
 	}
 
 	p.nextToken() // Consume closing }
-	return m, nil
+	return utils.NewMap(data), keys, nil
+}
+
+// parseModuleDef parses a module name optionally followed by an options map.
+func (p *parser) parseModuleDef() (*ModuleDef, error) {
+	def := &ModuleDef{Module: p.curTok.Literal}
+	p.nextToken()
+
+	if p.curTok.Type != token.LBRACE {
+		def.Options = utils.NewInterfaceMap()
+		return def, nil
+	}
+
+	options, err := p.parseMap()
+	if err != nil {
+		return nil, err
+	}
+	def.Options = options
+	return def, nil
 }
 
 func (p *parser) parseArray() (interface{}, error) {
@@ -369,6 +646,11 @@ func (p *parser) tokenError(expected ...token.Type) error {
 		allExpected[i] = t.String()
 	}
 
-	return fmt.Errorf("expected %s on line %d, got %s",
-		strings.Join(allExpected, " or "), p.curTok.Line, p.curTok.Type.String())
+	return p.errorf(p.curTok, "expected %s, got %s",
+		strings.Join(allExpected, " or "), p.curTok.Type.String())
+}
+
+// errorf creates an error prefixed with the source location of tok.
+func (p *parser) errorf(tok token.Token, format string, a ...interface{}) error {
+	return fmt.Errorf("%s: %s", position(p.filename, tok.Line, tok.Column), fmt.Sprintf(format, a...))
 }
@@ -2,9 +2,11 @@ package parser
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/lfkeitel/spartan/config/lexer"
+	"github.com/lfkeitel/spartan/event"
 	"github.com/lfkeitel/spartan/utils"
 )
 
@@ -131,7 +133,7 @@ func TestSimpleMapParser(t *testing.T) {
 }
 
 func TestComplexMapParser(t *testing.T) {
-	l := lexer.NewString(`{"key1" => ["val1", "val2"], "key2" => {"subkey1" => "otherval1"}}`)
+	l := lexer.NewString(`{"key1" => ["val1", "val2"], "key2" => {"subkey1" => "otherval1"}, "[a][b]" => 1}`)
 	p := newParser(l)
 	m, err := p.parseMap()
 	if err != nil {
@@ -139,8 +141,9 @@ func TestComplexMapParser(t *testing.T) {
 	}
 
 	expected := utils.NewMap(map[string]interface{}{
-		"key1": []string{"val1", "val2"},
-		"key2": utils.NewMap(map[string]interface{}{"subkey1": "otherval1"}),
+		"key1":   []string{"val1", "val2"},
+		"key2":   utils.NewMap(map[string]interface{}{"subkey1": "otherval1"}),
+		"[a][b]": 1,
 	})
 
 	if !reflect.DeepEqual(expected, m) {
@@ -148,6 +151,41 @@ func TestComplexMapParser(t *testing.T) {
 	}
 }
 
+func TestModuleDefParser(t *testing.T) {
+	l := lexer.NewString(`{codec => json {pretty => true, fields => ["a"]}, other => line}`)
+	p := newParser(l)
+	m, err := p.parseMap()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	expected := utils.NewMap(map[string]interface{}{
+		"codec": &ModuleDef{
+			Module:  "json",
+			Options: utils.NewMap(map[string]interface{}{"pretty": true, "fields": []string{"a"}}),
+		},
+		"other": &ModuleDef{
+			Module:  "line",
+			Options: utils.NewInterfaceMap(),
+		},
+	})
+
+	if !reflect.DeepEqual(expected, m) {
+		t.Fatalf("maps not equal. Expected %#v,\n\ngot %#v", expected, m)
+	}
+
+	name, options, ok := ModuleOption(m.Get("codec"))
+	if !ok || name != "json" || options["pretty"] != true {
+		t.Errorf("Expected json module with pretty option, got %s %v", name, options)
+	}
+	if name, options, ok := ModuleOption("line"); !ok || name != "line" || options != nil {
+		t.Errorf("Expected line module without options, got %s %v", name, options)
+	}
+	if _, _, ok := ModuleOption(5); ok {
+		t.Error("Expected 5 not to be a module")
+	}
+}
+
 func TestPipelineParser(t *testing.T) {
 	l := lexer.NewString(`{
 	date {
@@ -211,7 +249,8 @@ func TestPipelineParser(t *testing.T) {
 }
 
 func TestFileParser(t *testing.T) {
-	pf, err := ParseFile("./testdata/filters.conf")
+	const path = "./testdata/filters.conf"
+	pf, err := ParseFile(path)
 	if err != nil {
 		t.Fatalf("Unexpected error %s", err.Error())
 	}
@@ -222,6 +261,9 @@ func TestFileParser(t *testing.T) {
 			Options: utils.NewMap(map[string]interface{}{
 				"path": "",
 			}),
+			File:   path,
+			Line:   3,
+			Column: 5,
 		}},
 
 		Outputs: []*PipelineDef{{
@@ -229,6 +271,9 @@ func TestFileParser(t *testing.T) {
 			Options: utils.NewMap(map[string]interface{}{
 				"codec": "json",
 			}),
+			File:   path,
+			Line:   28,
+			Column: 5,
 		}},
 
 		Filters: []*PipelineDef{{
@@ -239,6 +284,9 @@ func TestFileParser(t *testing.T) {
 					`^(?<logdate>%{MONTHDAY}[-]%{MONTH}[-]%{YEAR} %{TIME}) client %{IP:clientip}#%{POSINT:clientport} \(%{GREEDYDATA:query}\): query: %{GREEDYDATA:target} IN %{GREEDYDATA:querytype} \(%{IP:dns}\)$`,
 				},
 			}),
+			File:        path,
+			Line:        10,
+			Column:      5,
 			Connections: []int{1},
 		}, {
 			Module: "date",
@@ -247,6 +295,9 @@ func TestFileParser(t *testing.T) {
 				"patterns": []string{"dd-MMM-yyyy HH:mm:ss.SSS"},
 				"timezone": "America/Chicago",
 			}),
+			File:        path,
+			Line:        15,
+			Column:      5,
 			Connections: []int{2},
 		}, {
 			Module: "mutate",
@@ -254,6 +305,9 @@ func TestFileParser(t *testing.T) {
 				"action": "remove_field",
 				"fields": []string{"logdate", "message"},
 			}),
+			File:   path,
+			Line:   21,
+			Column: 5,
 		}},
 	}
 
@@ -261,3 +315,163 @@ func TestFileParser(t *testing.T) {
 		t.Fatal("Incorrect ParsedFile")
 	}
 }
+
+func TestIfPipelineParser(t *testing.T) {
+	l := lexer.NewString(`{
+	grok {}
+
+	if [type] == "dns" {
+		date {}
+		mutate {}
+	} else if [type] == "dhcp" {
+		date {}
+	} else {
+		mutate {}
+	}
+
+	if "parsed" in [tags] {
+		mutate {}
+	}
+}`)
+	p := newParser(l)
+	pipeline, err := p.parsePipelineDefs()
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	expected := []struct {
+		module      string
+		connections []int
+	}{
+		{"grok", []int{1}},
+		{"if", []int{2, 4, 7}},
+		{"date", []int{3}},
+		{"mutate", nil},
+		{"if", []int{5, 6, -1}},
+		{"date", nil},
+		{"mutate", nil},
+		{"if", []int{8, -1, -1}},
+		{"mutate", nil},
+	}
+
+	if len(pipeline) != len(expected) {
+		t.Fatalf("Incorrect pipeline len. Expected %d, got %d", len(expected), len(pipeline))
+	}
+
+	for i, def := range pipeline {
+		if def.Module != expected[i].module {
+			t.Errorf("Incorrect module name at index %d. Expected %s, got %s", i, expected[i].module, def.Module)
+		}
+		if !reflect.DeepEqual(def.Connections, expected[i].connections) {
+			t.Errorf("Incorrect connections at index %d. Expected %v, got %v", i, expected[i].connections, def.Connections)
+		}
+		if (def.Module == "if") != (def.Condition != nil) {
+			t.Errorf("Incorrect condition at index %d", i)
+		}
+	}
+}
+
+func TestConditionEvaluation(t *testing.T) {
+	e := event.New("Hello world")
+	e.SetType("dns")
+	e.AddTag("parsed")
+	e.Set("port", "53")
+	e.Set("count", 10)
+	e.Set("size", uint64(1024))
+
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{`[type] == "dns"`, true},
+		{`[type] != "dns"`, false},
+		{`[port] == 53`, true},
+		{`[count] > 5`, true},
+		{`[count] < 5`, false},
+		{`[size] > 1000`, true},
+		{`[size] == 1024`, true},
+		{`"parsed" in [tags]`, true},
+		{`"failed" not in [tags]`, true},
+		{`[type] in ["dns", "dhcp"]`, true},
+		{`"world" in [message]`, true},
+		{`[missing]`, false},
+		{`[port]`, true},
+		{`![missing]`, true},
+		{`[type] == "dns" and [count] > 20`, false},
+		{`[type] == "dhcp" or [count] > 5`, true},
+		{`not ([type] == "dhcp" or [count] > 20)`, true},
+	}
+
+	for i, test := range tests {
+		p := newParser(lexer.NewString(test.condition))
+		cond, err := p.parseCondition()
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error %s", i+1, err.Error())
+		}
+
+		if cond.Evaluate(e) != test.expected {
+			t.Errorf("Test %d: Condition %s expected to be %t", i+1, test.condition, test.expected)
+		}
+	}
+}
+
+func TestGlobParser(t *testing.T) {
+	pf, err := ParseGlob("./testdata/glob/*.conf")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	if len(pf.Inputs) != 1 {
+		t.Fatalf("Incorrect inputs len. Expected 1, got %d", len(pf.Inputs))
+	}
+	if pf.Inputs[0].File != "testdata/glob/10-input.conf" {
+		t.Fatalf("Incorrect input file. Expected testdata/glob/10-input.conf, got %s", pf.Inputs[0].File)
+	}
+
+	expected := []struct {
+		module      string
+		file        string
+		connections []int
+	}{
+		{"grok", "testdata/glob/20-filter.conf", []int{1}},
+		{"if", "testdata/glob/20-filter.conf", []int{2, -1, 3}},
+		{"mutate", "testdata/glob/20-filter.conf", nil},
+		{"date", "testdata/glob/30-filter.conf", nil},
+	}
+
+	if len(pf.Filters) != len(expected) {
+		t.Fatalf("Incorrect filters len. Expected %d, got %d", len(expected), len(pf.Filters))
+	}
+
+	for i, def := range pf.Filters {
+		if def.Module != expected[i].module {
+			t.Errorf("Incorrect module name at index %d. Expected %s, got %s", i, expected[i].module, def.Module)
+		}
+		if def.File != expected[i].file {
+			t.Errorf("Incorrect file at index %d. Expected %s, got %s", i, expected[i].file, def.File)
+		}
+		if !reflect.DeepEqual(def.Connections, expected[i].connections) {
+			t.Errorf("Incorrect connections at index %d. Expected %v, got %v", i, expected[i].connections, def.Connections)
+		}
+	}
+
+	if len(pf.Outputs) != 1 {
+		t.Fatalf("Incorrect outputs len. Expected 1, got %d", len(pf.Outputs))
+	}
+}
+
+func TestGlobParserError(t *testing.T) {
+	_, err := ParseGlob("./testdata/globerror/*.conf")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "testdata/globerror/20-bad.conf:3:15") {
+		t.Fatalf("Error doesn't name file, line, and column: %s", err.Error())
+	}
+
+	_, err = ParseGlob("./testdata/nonexistent/*.conf")
+	if err != ErrNoFiles {
+		t.Fatalf("Expected ErrNoFiles, got %v", err)
+	}
+}
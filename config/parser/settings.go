@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lfkeitel/spartan/config/lexer"
+	"github.com/lfkeitel/spartan/config/token"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// A SettingsSection is a named section of settings.
+type SettingsSection struct {
+	Name    string
+	Options *utils.InterfaceMap
+
+	// File, Line, and Column are the source location of the section name.
+	// File is empty if the settings weren't read from a file.
+	File         string
+	Line, Column int
+
+	// keys are the tokens of the option names.
+	keys map[string]token.Token
+}
+
+// Position returns the location of the section in the configuration.
+func (s *SettingsSection) Position() string {
+	return position(s.File, s.Line, s.Column)
+}
+
+// KeyPosition returns the location of the option key in the configuration,
+// or the location of the section if the option doesn't exist.
+func (s *SettingsSection) KeyPosition(key string) string {
+	tok, exists := s.keys[key]
+	if !exists {
+		return s.Position()
+	}
+	return position(s.File, tok.Line, tok.Column)
+}
+
+// ParseSettingsFile parses a file of named setting sections. Each section
+// is a name followed by a map of options:
+//
+//	pipeline {
+//		filter_batch_size => 125
+//	}
+//
+// Sections are returned in the order written in the file.
+func ParseSettingsFile(path string) ([]*SettingsSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	p := newParser(lexer.New(file))
+	p.filename = path
+	return p.parseSettings()
+}
+
+// ParseSettingsString parses the given string as a settings configuration.
+func ParseSettingsString(s string) ([]*SettingsSection, error) {
+	return ParseSettings(strings.NewReader(s))
+}
+
+// ParseSettings parses a stream of named setting sections from an io.Reader.
+func ParseSettings(r io.Reader) ([]*SettingsSection, error) {
+	return newParser(lexer.New(r)).parseSettings()
+}
+
+func (p *parser) parseSettings() ([]*SettingsSection, error) {
+	var sections []*SettingsSection
+	defined := make(map[string]bool)
+
+	for p.curTok.Type != token.EOF {
+		if p.curTok.Type != token.IDENT {
+			return nil, p.tokenError(token.IDENT)
+		}
+
+		nameTok := p.curTok
+		if defined[nameTok.Literal] {
+			return nil, p.errorf(nameTok, "section %s already defined", nameTok.Literal)
+		}
+		defined[nameTok.Literal] = true
+		p.nextToken()
+
+		options, keys, err := p.parseMapKeys()
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, &SettingsSection{
+			Name:    nameTok.Literal,
+			Options: options,
+			File:    p.filename,
+			Line:    nameTok.Line,
+			Column:  nameTok.Column,
+			keys:    keys,
+		})
+	}
+
+	return sections, nil
+}
@@ -46,6 +46,8 @@ const (
 	RBRACE
 	LSQUARE
 	RSQUARE
+	LPAREN
+	RPAREN
 
 	// Keywords
 	keyword_beg
@@ -96,6 +98,8 @@ var tokens = [...]string{
 	RBRACE:  "}",
 	LSQUARE: "[",
 	RSQUARE: "]",
+	LPAREN:  "(",
+	RPAREN:  ")",
 
 	// Keywords
 	TRUE:   "true",
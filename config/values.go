@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+var errUnknownSetting = errors.New("unknown setting")
+
+func positiveInt(val interface{}) (int, error) {
+	i, ok := val.(int)
+	if !ok || i < 1 {
+		return 0, errors.New("must be a positive integer")
+	}
+	return i, nil
+}
+
+func duration(val interface{}) (time.Duration, error) {
+	s, ok := val.(string)
+	if !ok {
+		return 0, errors.New("must be a duration string such as \"5s\"")
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errors.New("must be a duration string such as \"5s\"")
+	}
+	return d, nil
+}
+
+func boolean(val interface{}) (bool, error) {
+	b, ok := val.(bool)
+	if !ok {
+		return false, errors.New("must be true or false")
+	}
+	return b, nil
+}
+
+func str(val interface{}) (string, error) {
+	s, ok := val.(string)
+	if !ok {
+		return "", errors.New("must be a string")
+	}
+	return s, nil
+}
+
+func stringSlice(val interface{}) ([]string, error) {
+	switch val := val.(type) {
+	case string:
+		return []string{val}, nil
+	case []string:
+		return val, nil
+	case []interface{}: // Empty array
+		if len(val) == 0 {
+			return []string{}, nil
+		}
+	}
+	return nil, errors.New("must be a string or array of strings")
+}
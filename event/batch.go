@@ -0,0 +1,40 @@
+package event
+
+import "time"
+
+// CollectBatch receives up to batchSize events from in. The batch is returned
+// early if flushInterval passes after the first event is received or dying is closed.
+// stopping will be true if dying was closed. The returned batch never contains nil
+// events but may be empty.
+func CollectBatch(in <-chan *Event, batchSize int, flushInterval time.Duration, dying <-chan struct{}) (batch []*Event, stopping bool) {
+	batch = make([]*Event, 0, batchSize)
+
+	var flush <-chan time.Time // nil until the first event starts the timer
+	var timer *time.Timer
+
+	for len(batch) < batchSize {
+		select {
+		case e := <-in:
+			if e == nil {
+				continue
+			}
+			if flush == nil && flushInterval > 0 {
+				timer = time.NewTimer(flushInterval)
+				flush = timer.C
+			}
+			batch = append(batch, e)
+		case <-flush:
+			return batch, false
+		case <-dying:
+			if timer != nil {
+				timer.Stop()
+			}
+			return batch, true
+		}
+	}
+
+	if timer != nil {
+		timer.Stop()
+	}
+	return batch, false
+}
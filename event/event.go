@@ -1,6 +1,7 @@
 package event
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -22,6 +23,9 @@ const (
 // and should be manipulated using the corresponding Get and Set methods.
 // If any of these fields are manipulated using the generic Get/Set methods,
 // the request will be directed to the correct Get/Set method.
+//
+// Field names given to the generic methods may be paths such as [a][b][c]
+// to work with fields in nested maps. [field] is the same as field.
 type Event struct {
 	sync.RWMutex
 	timestamp time.Time
@@ -44,25 +48,62 @@ func New(message string) *Event {
 
 // Squash reduces the Event to an InterfaceMap where the map keys
 // are the Event's field names. The returned map is safe for the caller to
-// manipulate as it's a copy of the underlying map in the Event. This method
-// is intented for output/codecs modules to encode the Event.
+// manipulate, including with nested paths, as it's a deep copy of the maps
+// and arrays in the Event. This method is intented for output/codecs modules
+// to encode the Event.
 func (e *Event) Squash() *utils.InterfaceMap {
 	e.Lock()
-	dataCopy := e.data.Copy()
+	dataCopy := utils.DeepCopy(e.data).(*utils.InterfaceMap)
 
 	if e.message != "" {
 		dataCopy.Set(messageField, e.message)
 	}
 	dataCopy.Set(typeField, e.etype)
 	dataCopy.Set(timestampField, e.timestamp)
-	dataCopy.Set(tagsField, e.tags)
+	dataCopy.Set(tagsField, utils.DeepCopy(e.tags))
 	e.Unlock()
 	return dataCopy
 }
 
-// Set the field key to val.
+// ReadFields calls fn with the names of the Event's fields, including the same
+// protected fields as Squash, in sorted order and a function returning the value
+// of a named field. The Event is locked while fn runs so fn must not call other
+// methods of the Event or modify values. This method lets codecs encode an Event
+// without copying it.
+func (e *Event) ReadFields(fn func(keys []string, get func(key string) interface{})) {
+	e.RLock()
+	defer e.RUnlock()
+
+	data := e.data.Map()
+	keys := make([]string, 0, len(data)+4)
+	for key := range data {
+		keys = append(keys, key)
+	}
+	if e.message != "" {
+		keys = append(keys, messageField)
+	}
+	keys = append(keys, typeField, timestampField, tagsField)
+	sort.Strings(keys)
+
+	fn(keys, func(key string) interface{} {
+		switch key {
+		case messageField:
+			return e.message
+		case typeField:
+			return e.etype
+		case timestampField:
+			return e.timestamp
+		case tagsField:
+			return e.tags
+		}
+		return data[key]
+	})
+}
+
+// Set the field key to val. Maps along a nested path are created as needed.
 func (e *Event) Set(key string, val interface{}) {
-	if e.setSpecial(key, val) {
+	key, nested := fieldKey(key)
+	if e.setSpecial(key, val) || nested && isSpecialPath(key) {
 		return
 	}
 	e.Lock()
@@ -93,9 +134,13 @@ func (e *Event) setSpecial(key string, val interface{}) bool {
 
 // Get the value of field key
 func (e *Event) Get(key string) interface{} {
+	key, nested := fieldKey(key)
 	if val, exists := e.getSpecial(key); exists {
 		return val
 	}
+	if nested && isSpecialPath(key) {
+		return nil
+	}
 	e.RLock()
 	defer e.RUnlock()
 	if val, exists := e.data.GetOK(key); exists {
@@ -111,24 +156,62 @@ func (e *Event) getSpecial(key string) (interface{}, bool) {
 	case typeField:
 		return e.GetType(), true
 	case tagsField:
-		return e.GetTags, true
+		return e.GetTags(), true
 	case timestampField:
 		return e.GetTimestamp(), true
 	}
 	return nil, false
 }
 
+// HasField returns if the field key exists.
+func (e *Event) HasField(key string) bool {
+	key, nested := fieldKey(key)
+	if val, exists := e.getSpecial(key); exists {
+		return key != messageField || val != ""
+	}
+	if nested && isSpecialPath(key) {
+		return false
+	}
+	e.RLock()
+	defer e.RUnlock()
+	return e.data.KeyExists(key)
+}
+
 // RemoveField deletes the field key
 func (e *Event) RemoveField(key string) {
-	if ok := e.removeSpecial(key); ok {
+	key, nested := fieldKey(key)
+	if ok := e.removeSpecial(key); ok || nested && isSpecialPath(key) {
 		return
 	}
-	e.RLock()
-	defer e.RUnlock()
+	e.Lock()
+	defer e.Unlock()
 	e.data.Delete(key)
 	return
 }
 
+// fieldKey reduces a single key path such as [field] to the key. nested is
+// true if key is a path into nested maps.
+func fieldKey(key string) (string, bool) {
+	if key == "" || key[0] != '[' {
+		return key, false
+	}
+	path := utils.ParseFieldPath(key)
+	if len(path) == 1 {
+		return path[0], false
+	}
+	return key, true
+}
+
+// isSpecialPath returns if the nested path key starts at a protected field.
+// Protected fields don't hold nested maps.
+func isSpecialPath(key string) bool {
+	switch utils.ParseFieldPath(key)[0] {
+	case messageField, timestampField, typeField, tagsField:
+		return true
+	}
+	return false
+}
+
 func (e *Event) removeSpecial(key string) bool {
 	switch key {
 	case messageField:
@@ -0,0 +1,48 @@
+package event
+
+import "testing"
+
+func TestEventFieldPaths(t *testing.T) {
+	e := New("hello")
+	e.Set("[type]", "app")
+	e.Set("[http][request][method]", "GET")
+	e.Set("[message][nested]", "ignored")
+
+	if e.GetType() != "app" || e.Get("[message]") != "hello" {
+		t.Errorf("Expected type app and message hello, got %q and %#v", e.GetType(), e.Get("[message]"))
+	}
+	if e.Get("[http][request][method]") != "GET" {
+		t.Errorf("Expected GET, got %#v", e.Get("[http][request][method]"))
+	}
+	if e.Get("[message][nested]") != nil || e.HasField("[tags][0]") {
+		t.Error("Nested path set under a protected field")
+	}
+
+	if !e.HasField("[http][request]") || e.HasField("[http][response]") || !e.HasField("message") {
+		t.Error("Unexpected HasField result")
+	}
+
+	e.RemoveField("[http][request][method]")
+	if e.HasField("[http][request][method]") || !e.HasField("[http][request]") {
+		t.Error("Nested field not removed")
+	}
+
+	e.RemoveField("[message]")
+	if e.HasField("message") {
+		t.Error("Message not removed")
+	}
+}
+
+func TestEventSquashCopy(t *testing.T) {
+	e := New("hello")
+	e.Set("[http][method]", "GET")
+	e.AddTag("a")
+
+	data := e.Squash()
+	data.Set("[http][method]", "POST")
+	data.Get("tags").([]string)[0] = "b"
+
+	if e.Get("[http][method]") != "GET" || !e.HasTag("a") {
+		t.Error("Changing a squashed Event changed the Event")
+	}
+}
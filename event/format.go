@@ -0,0 +1,213 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var fieldRefRegex = regexp.MustCompile(`%\{([^}]+)\}`)
+
+// valueTimeLayout is the layout of times in formatted values.
+const valueTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Sprintf replaces references in format with values from the Event.
+// %{field} is replaced with the value of field, %{[a][b]} with the value of
+// key b in the map field a, and %{+YYYY.MM.dd} with @timestamp in UTC formatted
+// with a Joda-Time pattern (%{+%s} is seconds since the Unix epoch). References
+// to fields that don't exist are left unchanged.
+func (e *Event) Sprintf(format string) string {
+	if !strings.Contains(format, "%{") {
+		return format
+	}
+
+	return fieldRefRegex.ReplaceAllStringFunc(format, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		if name[0] == '+' {
+			t := e.GetTimestamp().UTC()
+			if name == "+%s" {
+				return strconv.FormatInt(t.Unix(), 10)
+			}
+			return FormatTime(t, name[1:])
+		}
+
+		val := e.Get(name)
+		if val == nil {
+			return ref
+		}
+		return ValueString(val)
+	})
+}
+
+// ValueString returns the text form of a field value. Times are formatted as
+// RFC3339 with milliseconds, maps and arrays as JSON.
+func ValueString(val interface{}) string {
+	switch val := val.(type) {
+	case string:
+		return val
+	case time.Time:
+		return val.Format(valueTimeLayout)
+	case fmt.Stringer:
+		return val.String()
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(val)
+	}
+
+	if j, err := json.Marshal(val); err == nil {
+		return string(j)
+	}
+	return fmt.Sprint(val)
+}
+
+// FormatTime formats t with a Joda-Time pattern such as "YYYY.MM.dd HH:mm:ss.SSS".
+// Letters are pattern fields and text in single quotes is copied as is, two single
+// quotes are a quote. The fields are:
+//
+//	y, Y  year (yy is two digits)      x  ISO week year
+//	M     month (MMM Jan, MMMM January) w  ISO week of year
+//	d     day of month                 D  day of year
+//	E     weekday (E Mon, EEEE Monday) a  AM or PM
+//	H     hour (0-23)                  h  hour (1-12)
+//	m     minute                       s  second
+//	S     fraction of a second         z  time zone abbreviation
+//	Z     offset (Z -0700, ZZ -07:00, ZZZ time zone name)
+//
+// Repeated letters set the minimum number of digits. Other letters are copied as is.
+func FormatTime(t time.Time, pattern string) string {
+	buf := make([]byte, 0, len(pattern)+10)
+
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+
+		if c == '\'' {
+			end := strings.IndexByte(pattern[i+1:], '\'')
+			switch {
+			case end == 0: // ''
+				buf = append(buf, '\'')
+				i += 2
+			case end < 0:
+				buf = append(buf, pattern[i+1:]...)
+				i = len(pattern)
+			default:
+				buf = append(buf, pattern[i+1:i+1+end]...)
+				i += end + 2
+			}
+			continue
+		}
+
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			_, size := utf8.DecodeRuneInString(pattern[i:])
+			buf = append(buf, pattern[i:i+size]...)
+			i += size
+			continue
+		}
+
+		n := 1
+		for i+n < len(pattern) && pattern[i+n] == c {
+			n++
+		}
+		i += n
+		buf = appendTimeField(buf, t, c, n)
+	}
+	return string(buf)
+}
+
+// appendTimeField appends the pattern field c repeated n times.
+func appendTimeField(buf []byte, t time.Time, c byte, n int) []byte {
+	switch c {
+	case 'y', 'Y':
+		if n == 2 {
+			return appendPadded(buf, t.Year()%100, 2)
+		}
+		return appendPadded(buf, t.Year(), n)
+	case 'x':
+		year, _ := t.ISOWeek()
+		if n == 2 {
+			return appendPadded(buf, year%100, 2)
+		}
+		return appendPadded(buf, year, n)
+	case 'w':
+		_, week := t.ISOWeek()
+		return appendPadded(buf, week, n)
+	case 'M':
+		switch {
+		case n >= 4:
+			return append(buf, t.Month().String()...)
+		case n == 3:
+			return append(buf, t.Month().String()[:3]...)
+		}
+		return appendPadded(buf, int(t.Month()), n)
+	case 'd':
+		return appendPadded(buf, t.Day(), n)
+	case 'D':
+		return appendPadded(buf, t.YearDay(), n)
+	case 'E':
+		if n >= 4 {
+			return append(buf, t.Weekday().String()...)
+		}
+		return append(buf, t.Weekday().String()[:3]...)
+	case 'a':
+		if t.Hour() < 12 {
+			return append(buf, "AM"...)
+		}
+		return append(buf, "PM"...)
+	case 'H':
+		return appendPadded(buf, t.Hour(), n)
+	case 'h':
+		hour := t.Hour() % 12
+		if hour == 0 {
+			hour = 12
+		}
+		return appendPadded(buf, hour, n)
+	case 'm':
+		return appendPadded(buf, t.Minute(), n)
+	case 's':
+		return appendPadded(buf, t.Second(), n)
+	case 'S':
+		digits := n
+		if digits > 9 {
+			digits = 9
+		}
+		frac := t.Nanosecond()
+		for j := digits; j < 9; j++ {
+			frac /= 10
+		}
+		buf = appendPadded(buf, frac, digits)
+		for j := digits; j < n; j++ {
+			buf = append(buf, '0')
+		}
+		return buf
+	case 'z':
+		return append(buf, t.Format("MST")...)
+	case 'Z':
+		switch n {
+		case 1:
+			return append(buf, t.Format("-0700")...)
+		case 2:
+			return append(buf, t.Format("-07:00")...)
+		}
+		return append(buf, t.Location().String()...)
+	}
+
+	for j := 0; j < n; j++ {
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendPadded appends i with at least width digits.
+func appendPadded(buf []byte, i, width int) []byte {
+	if i < 0 {
+		buf = append(buf, '-')
+		i = -i
+	}
+	s := strconv.Itoa(i)
+	for j := len(s); j < width; j++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, s...)
+}
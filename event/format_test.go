@@ -0,0 +1,42 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/utils"
+)
+
+func TestSprintf(t *testing.T) {
+	e := New("hello")
+	e.SetTimestamp(time.Date(2017, time.March, 5, 22, 7, 3, 123456789, time.FixedZone("EST", -5*3600)))
+	e.SetType("app")
+	e.Set("status", 200)
+	e.Set("request", map[string]interface{}{"method": "GET", "headers": map[string]string{"Host": "web1"}})
+	nested := utils.NewInterfaceMap()
+	nested.Set("b", "deep")
+	e.Set("a", nested)
+
+	tests := map[string]string{
+		"no references":                      "no references",
+		"%{type}-%{+YYYY.MM.dd}":             "app-2017.03.06",
+		"%{message} %{status}":               "hello 200",
+		"%{[request][method]} %{[a][b]}":     "GET deep",
+		"%{[request][headers][Host]}":        "web1",
+		"%{missing} %{[request][missing]}":   "%{missing} %{[request][missing]}",
+		"%{[request][method][x]} %{[a]b}":    "%{[request][method][x]} %{[a]b}",
+		"%{[status]}":                        "200",
+		"%{+%s}":                             "1488769623",
+		"%{+HH:mm:ss.SSS 'at' yy-M-d EEE}":   "03:07:03.123 at 17-3-6 Mon",
+		"%{@timestamp}":                      "2017-03-05T22:07:03.123-05:00",
+		"%{[request][headers]}":              `{"Host":"web1"}`,
+		"%{+MMMM MMM xxxx-ww D ''h a Z ZZ}":  "March Mar 2017-10 65 '3 AM +0000 +00:00",
+		"%{+SSSSSSSSSSS EEEE 'unterminated}": "12345678900 Monday unterminated",
+	}
+
+	for format, expected := range tests {
+		if formatted := e.Sprintf(format); formatted != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, formatted)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package filters
+
+import (
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+// A conditional implements an if statement in a filter pipeline. Each event in a
+// batch is routed to the true or false branch depending on the condition. The
+// results of both branches are then rejoined in the order of the original batch
+// and sent to the next Filter. Events removed by a branch stay removed.
+type conditional struct {
+	condition parser.Condition
+	ifTrue    Filter
+	ifFalse   Filter
+	next      Filter
+}
+
+func newConditional(c parser.Condition) *conditional {
+	return &conditional{condition: c}
+}
+
+// SetNext sets the next Filter in line.
+func (f *conditional) SetNext(next Filter) {
+	f.next = next
+}
+
+// Run processes a batch.
+func (f *conditional) Run(batch []*event.Event) []*event.Event {
+	trueBatch := make([]*event.Event, 0, len(batch))
+	falseBatch := make([]*event.Event, 0, len(batch))
+
+	for _, event := range batch {
+		if event == nil {
+			continue
+		}
+
+		if f.condition.Evaluate(event) {
+			trueBatch = append(trueBatch, event)
+		} else {
+			falseBatch = append(falseBatch, event)
+		}
+	}
+
+	if len(trueBatch) > 0 {
+		trueBatch = f.ifTrue.Run(trueBatch)
+	}
+	if len(falseBatch) > 0 {
+		falseBatch = f.ifFalse.Run(falseBatch)
+	}
+
+	return f.next.Run(rejoin(batch, trueBatch, falseBatch))
+}
+
+// rejoin returns the events of batch that are in the branch results in the order
+// of batch. Events a branch added that aren't in batch come last. The returned
+// slice uses the memory of batch.
+func rejoin(batch []*event.Event, results ...[]*event.Event) []*event.Event {
+	kept := make(map[*event.Event]bool, len(batch))
+	for _, result := range results {
+		for _, e := range result {
+			if e != nil {
+				kept[e] = true
+			}
+		}
+	}
+
+	n := 0
+	for _, e := range batch {
+		if kept[e] {
+			batch[n] = e
+			n++
+			delete(kept, e)
+		}
+	}
+	joined := batch[:n]
+
+	for _, result := range results {
+		for _, e := range result {
+			if kept[e] {
+				joined = append(joined, e)
+				delete(kept, e)
+			}
+		}
+	}
+	return joined
+}
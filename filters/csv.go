@@ -0,0 +1,250 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+func init() {
+	register("csv", newCSVFilter)
+}
+
+var csvConvertTypes = []string{"integer", "float", "boolean", "string"}
+
+type csvConfig struct {
+	field      string
+	separator  rune
+	quote      rune
+	columns    []string
+	autodetect bool
+	skipHeader bool
+	convert    map[string]string
+	target     string
+}
+
+// The CSVFilter splits a field into columns. Columns are named by the columns
+// option, or by the first line seen if autodetect_column_names is set. Columns
+// without a name are named column1, column2, etc. Columns can be converted to
+// an integer, float, or boolean with the convert option. Column values are set
+// as fields, or in a map in the target field if set.
+//
+// The first line of a file is only known with a single filter worker, column
+// name detection requires the filter workers setting to be 1. CheckWorkers
+// reports an error otherwise.
+type CSVFilter struct {
+	next   Filter
+	config *csvConfig
+}
+
+func newCSVFilter(options map[string]interface{}) (Filter, error) {
+	options = checkOptionsMap(options)
+	f := &CSVFilter{config: &csvConfig{
+		field:     "message",
+		separator: ',',
+		quote:     '"',
+	}}
+	if err := f.setConfig(options); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *CSVFilter) setConfig(options map[string]interface{}) error {
+	if s, exists := options["field"]; exists {
+		field, ok := s.(string)
+		if !ok {
+			return errors.New("Field must be a string")
+		}
+		f.config.field = field
+	}
+
+	for _, opt := range []struct {
+		name string
+		val  *rune
+	}{
+		{"separator", &f.config.separator},
+		{"quote_char", &f.config.quote},
+	} {
+		if s, exists := options[opt.name]; exists {
+			str, ok := s.(string)
+			if !ok || utf8.RuneCountInString(str) != 1 {
+				return fmt.Errorf("%s must be a single character", opt.name)
+			}
+			*opt.val, _ = utf8.DecodeRuneInString(str)
+		}
+	}
+	if f.config.separator == f.config.quote {
+		return errors.New("separator and quote_char must be different")
+	}
+
+	if s, exists := options["columns"]; exists {
+		switch s := s.(type) {
+		case []string:
+			f.config.columns = s
+		case []interface{}:
+			// Empty array
+		default:
+			return errors.New("Columns must be an array of strings")
+		}
+	}
+
+	for _, opt := range []struct {
+		name string
+		val  *bool
+	}{
+		{"autodetect_column_names", &f.config.autodetect},
+		{"skip_header", &f.config.skipHeader},
+	} {
+		if s, exists := options[opt.name]; exists {
+			b, ok := s.(bool)
+			if !ok {
+				return fmt.Errorf("%s must be a boolean", opt.name)
+			}
+			*opt.val = b
+		}
+	}
+	if f.config.autodetect && len(f.config.columns) > 0 {
+		return errors.New("Columns can't be set with autodetect_column_names")
+	}
+
+	if s, exists := options["convert"]; exists {
+		m, ok := s.(*utils.InterfaceMap)
+		if !ok {
+			return errors.New("Convert must be a map of column names to types")
+		}
+		f.config.convert = make(map[string]string, m.Len())
+		for column, t := range m.Map() {
+			str, ok := t.(string)
+			if !ok || !utils.StringInSlice(str, csvConvertTypes) {
+				return fmt.Errorf("Convert type for %s must be one of %v", column, csvConvertTypes)
+			}
+			f.config.convert[column] = str
+		}
+	}
+
+	if s, exists := options["target"]; exists {
+		target, ok := s.(string)
+		if !ok {
+			return errors.New("Target must be a string")
+		}
+		f.config.target = target
+	}
+
+	return nil
+}
+
+func (f *CSVFilter) checkWorkers(workers int) error {
+	if f.config.autodetect && workers > 1 {
+		return errors.New("autodetect_column_names requires filter_workers => 1")
+	}
+	return nil
+}
+
+// SetNext sets the next Filter in line.
+func (f *CSVFilter) SetNext(next Filter) {
+	f.next = next
+}
+
+// Run processes a batch. Header lines are removed from the batch.
+func (f *CSVFilter) Run(batch []*event.Event) []*event.Event {
+	n := 0
+	for _, event := range batch {
+		if event == nil || f.parse(event) {
+			batch[n] = event
+			n++
+		}
+	}
+	return f.next.Run(batch[:n])
+}
+
+// parse sets the columns of e. False is returned if e is a header line.
+func (f *CSVFilter) parse(e *event.Event) bool {
+	fieldStr, ok := e.Get(f.config.field).(string)
+	if !ok {
+		utils.Log.Debugf("Field %s doesn't exist or isn't a string", f.config.field)
+		e.AddTag("_csvparsefailure")
+		return true
+	}
+
+	values, err := utils.SplitCSV(fieldStr, f.config.separator, f.config.quote)
+	if err != nil {
+		utils.Log.Debugf("CSV filter: %v", err)
+		e.AddTag("_csvparsefailure")
+		return true
+	}
+
+	if f.config.autodetect && f.config.columns == nil {
+		f.config.columns = values
+		return false
+	}
+	if f.config.skipHeader && equalStrings(values, f.config.columns) {
+		return false
+	}
+
+	var target map[string]interface{}
+	if f.config.target != "" {
+		target = make(map[string]interface{}, len(values))
+	}
+
+	for i, val := range values {
+		name := fmt.Sprintf("column%d", i+1)
+		if i < len(f.config.columns) {
+			name = f.config.columns[i]
+		}
+
+		converted, ok := convertCSVValue(val, f.config.convert[name])
+		if !ok {
+			e.AddTag("_csvtypefailure")
+		}
+		if target != nil {
+			target[name] = converted
+		} else {
+			e.Set(name, converted)
+		}
+	}
+
+	if target != nil {
+		e.Set(f.config.target, target)
+	}
+	return true
+}
+
+// convertCSVValue converts val to type t. If val can't be converted, val
+// and false are returned.
+func convertCSVValue(val, t string) (interface{}, bool) {
+	var converted interface{}
+	var err error
+
+	switch t {
+	case "integer":
+		converted, err = strconv.Atoi(val)
+	case "float":
+		converted, err = strconv.ParseFloat(val, 64)
+	case "boolean":
+		converted, err = strconv.ParseBool(val)
+	default:
+		return val, true
+	}
+
+	if err != nil {
+		return val, false
+	}
+	return converted, true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
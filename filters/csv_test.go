@@ -0,0 +1,128 @@
+package filters
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func csvTestPipeline(t *testing.T, config string) Filter {
+	pf, err := parser.ParseString("filter {\ncsv {\n" + config + "\n}\n}")
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	pipeline, err := GeneratePipeline(pf.Filters)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	return pipeline
+}
+
+func TestCSVFilter(t *testing.T) {
+	pipeline := csvTestPipeline(t, `
+	columns => ["name", "age", "ratio", "admin"]
+	convert => {
+		age => "integer"
+		ratio => "float"
+		admin => "boolean"
+	}`)
+
+	batch := pipeline.Run([]*event.Event{
+		event.New(`alice,30,0.5,true,"extra, column"`),
+		event.New(`bob,old,1,false`),
+		event.New(`"unterminated`),
+	})
+	if len(batch) != 3 {
+		t.Fatalf("Incorrect batch len. Expected 3, got %d", len(batch))
+	}
+
+	expected := map[string]interface{}{
+		"name":    "alice",
+		"age":     30,
+		"ratio":   0.5,
+		"admin":   true,
+		"column5": "extra, column",
+	}
+	for key, val := range expected {
+		if batch[0].Get(key) != val {
+			t.Errorf("Expected %s=%#v, got %#v", key, val, batch[0].Get(key))
+		}
+	}
+
+	if batch[1].Get("age") != "old" || !reflect.DeepEqual(batch[1].GetTags(), []string{"_csvtypefailure"}) {
+		t.Errorf("Expected unconverted age and _csvtypefailure tag, got %#v and %v", batch[1].Get("age"), batch[1].GetTags())
+	}
+	if !reflect.DeepEqual(batch[2].GetTags(), []string{"_csvparsefailure"}) {
+		t.Errorf("Expected _csvparsefailure tag, got %v", batch[2].GetTags())
+	}
+}
+
+func TestCSVFilterHeader(t *testing.T) {
+	pipeline := csvTestPipeline(t, `
+	separator => ";"
+	autodetect_column_names => true
+	skip_header => true
+	target => "row"`)
+
+	batch := pipeline.Run([]*event.Event{
+		event.New("host;status"),
+		event.New("web1;200"),
+		event.New("host;status"),
+	})
+	batch = append(batch, pipeline.Run([]*event.Event{event.New("web2;404")})...)
+	if len(batch) != 2 {
+		t.Fatalf("Incorrect batch len. Expected 2, got %d", len(batch))
+	}
+
+	expected := map[string]interface{}{"host": "web2", "status": "404"}
+	if row := batch[1].Get("row"); !reflect.DeepEqual(row, expected) {
+		t.Errorf("Expected row %v, got %v", expected, row)
+	}
+	if batch[0].Get("host") != nil {
+		t.Error("Columns set outside of target")
+	}
+}
+
+func TestCSVFilterOptions(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{"separator": ",,"},
+		{"separator": "'", "quote_char": "'"},
+		{"columns": "name"},
+		{"autodetect_column_names": true, "columns": []string{"name"}},
+		{"convert": map[string]interface{}{"age": "integer"}},
+	}
+	for _, options := range invalid {
+		if _, err := newCSVFilter(options); err == nil {
+			t.Errorf("Expected error with options %v", options)
+		}
+	}
+
+	pf, err := parser.ParseString(`filter { csv { convert => { age => "number" } } }`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+	if _, err := GeneratePipeline(pf.Filters); err == nil {
+		t.Error("Expected error with an invalid convert type")
+	}
+}
+
+func TestCSVFilterWorkers(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	csv { autodetect_column_names => true }
+	if [type] == "a" {
+		csv { columns => ["a"] }
+	}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	if errs := CheckWorkers(pf.Filters, 1); len(errs) != 0 {
+		t.Errorf("Expected no errors with 1 worker, got %v", errs)
+	}
+	if errs := CheckWorkers(pf.Filters, 2); len(errs) != 1 {
+		t.Errorf("Expected 1 error with 2 workers, got %v", errs)
+	}
+}
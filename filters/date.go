@@ -15,7 +15,7 @@ func init() {
 type dateConfig struct {
 	field    string
 	patterns []string
-	timezone string
+	timezone *time.Location
 }
 
 // The DateFilter is used to set the canonical @timestamp field of an Event.
@@ -37,7 +37,11 @@ func newDateFilter(options map[string]interface{}) (Filter, error) {
 
 func (f *DateFilter) setConfig(options map[string]interface{}) error {
 	if s, exists := options["field"]; exists {
-		f.config.field = s.(string)
+		field, ok := s.(string)
+		if !ok {
+			return errors.New("Field must be a string")
+		}
+		f.config.field = field
 	} else {
 		return errors.New("Field option required")
 	}
@@ -55,15 +59,44 @@ func (f *DateFilter) setConfig(options map[string]interface{}) error {
 		return errors.New("Patterns option required")
 	}
 
+	for _, p := range f.config.patterns {
+		if !isValidDateLayout(p) {
+			return fmt.Errorf("Invalid date pattern %s", p)
+		}
+	}
+
+	timezone := "UTC"
 	if s, exists := options["timezone"]; exists {
-		f.config.timezone = s.(string)
-	} else {
-		f.config.timezone = "UTC"
+		tz, ok := s.(string)
+		if !ok {
+			return errors.New("Timezone must be a string")
+		}
+		timezone = tz
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("Invalid timezone %s", timezone)
 	}
+	f.config.timezone = loc
 
 	return nil
 }
 
+// dateLayoutReference is used to check if a date layout contains any time elements.
+var dateLayoutReference = time.Date(2017, time.November, 23, 21, 36, 48, 987654321, time.UTC)
+
+// isValidDateLayout checks that layout is a Go time layout that can parse
+// the dates it formats. A layout without any time elements is not valid.
+func isValidDateLayout(layout string) bool {
+	formatted := dateLayoutReference.Format(layout)
+	if formatted == layout {
+		return false
+	}
+	_, err := time.Parse(layout, formatted)
+	return err == nil
+}
+
 // SetNext sets the next Filter in line.
 func (f *DateFilter) SetNext(next Filter) {
 	f.next = next
@@ -72,6 +105,10 @@ func (f *DateFilter) SetNext(next Filter) {
 // Run processes a batch.
 func (f *DateFilter) Run(batch []*event.Event) []*event.Event {
 	for _, event := range batch {
+		if event == nil {
+			continue
+		}
+
 		field := event.Get(f.config.field)
 		if field == nil {
 			continue
@@ -82,14 +119,8 @@ func (f *DateFilter) Run(batch []*event.Event) []*event.Event {
 			continue
 		}
 
-		loc, err := time.LoadLocation(f.config.timezone)
-		if err != nil {
-			fmt.Printf("Invalid timezone %s", f.config.timezone)
-			continue
-		}
-
 		for _, p := range f.config.patterns {
-			newTime, err := time.ParseInLocation(p, fieldStr, loc)
+			newTime, err := time.ParseInLocation(p, fieldStr, f.config.timezone)
 			if err != nil {
 				continue
 			}
@@ -1,30 +1,48 @@
 package filters
 
 import (
-	"fmt"
+	"sync"
+	"time"
 
 	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
 
 	tomb "gopkg.in/tomb.v2"
 )
 
 // A FilterController is responsible for collecting a batch of events from inputs
 // and start a chain of Filters to process the batch. Events are then sent to
-// outputs.
+// outputs. Batches are processed by one or more workers, each running its own
+// filter pipeline.
 type FilterController struct {
-	start     Filter
-	batchSize int
-	t         tomb.Tomb
-	in        <-chan *event.Event
-	out       chan<- *event.Event
+	pipelines     []Filter
+	batchSize     int
+	flushInterval time.Duration
+	preserveOrder bool
+	t             tomb.Tomb
+	in            <-chan *event.Event
+	out           chan<- *event.Event
 }
 
-// NewFilterController creates a new controller using start as the root Filter
-// and batchSize as the number of events to queue before processing.
-func NewFilterController(start Filter, batchSize int) *FilterController {
+// A filterBatch is a batch of events with its position in the input stream.
+type filterBatch struct {
+	seq    uint64
+	events []*event.Event
+}
+
+// NewFilterController creates a new controller using pipelines as the root Filters
+// and batchSize as the number of events to queue before processing. A worker is
+// started for each pipeline, Filter instances must not be shared between pipelines.
+// A partial batch is processed once flushInterval has passed since its first event
+// arrived. A flushInterval of 0 disables time based flushing. If preserveOrder is
+// true, batches are sent to outputs in the order they were collected regardless of
+// which worker finishes first.
+func NewFilterController(pipelines []Filter, batchSize int, flushInterval time.Duration, preserveOrder bool) *FilterController {
 	return &FilterController{
-		start:     start,
-		batchSize: batchSize,
+		pipelines:     pipelines,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		preserveOrder: preserveOrder,
 	}
 }
 
@@ -40,46 +58,94 @@ func (f *FilterController) Start(in, out chan *event.Event) error {
 
 // Close will gracefully shutdown the Controller. Collection from the input channel
 // is immediately stopped and all in-flight events are processed, sent to outputs, and
-// then the controller go routine exits.
+// then the controller go routines exit.
 func (f *FilterController) Close() error {
 	f.t.Kill(nil)
 	return f.t.Wait()
 }
 
 func (f *FilterController) run() error {
-	fmt.Println("Filter Pipeline started")
+	utils.Log.Infof("Filter pipeline started with %d worker(s)", len(f.pipelines))
+
+	work := make(chan *filterBatch)
+	results := make(chan *filterBatch)
+
+	var workers sync.WaitGroup
+	for _, pipeline := range f.pipelines {
+		workers.Add(1)
+		go func(pipeline Filter) {
+			f.worker(pipeline, work, results)
+			workers.Done()
+		}(pipeline)
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		f.send(results)
+		close(sent)
+	}()
+
+	var seq uint64
 	for {
-		select {
-		case <-f.t.Dying():
-			return nil
-		default:
+		batch, stopping := event.CollectBatch(f.in, f.batchSize, f.flushInterval, f.t.Dying())
+
+		if len(batch) > 0 {
+			work <- &filterBatch{seq: seq, events: batch}
+			seq++
 		}
 
-		currentBatch := 0
-		batch := make([]*event.Event, f.batchSize)
-		stopping := false
-
-	CURRENT:
-		for currentBatch < f.batchSize {
-			select {
-			case event := <-f.in:
-				batch[currentBatch] = event
-				currentBatch++
-			case <-f.t.Dying():
-				stopping = true
-				break CURRENT
-			}
+		if stopping {
+			break
 		}
+	}
 
-		fmt.Println("Processing batch")
-		batch = f.start.Run(batch)
+	// Let the workers finish in-flight batches before the results are closed
+	close(work)
+	workers.Wait()
+	close(results)
+	<-sent
+	return nil
+}
 
-		for _, event := range batch {
-			f.out <- event
+// worker runs batches through pipeline until the work channel is closed.
+func (f *FilterController) worker(pipeline Filter, work <-chan *filterBatch, results chan<- *filterBatch) {
+	for batch := range work {
+		utils.Log.Debugf("Processing filter batch of %d events", len(batch.events))
+		batch.events = pipeline.Run(batch.events)
+		results <- batch
+	}
+}
+
+// send forwards processed batches to the out channel until results is closed.
+// If the controller preserves order, batches that finish early are held until
+// all batches before them have been sent.
+func (f *FilterController) send(results <-chan *filterBatch) {
+	var next uint64
+	pending := make(map[uint64]*filterBatch)
+
+	for batch := range results {
+		if !f.preserveOrder {
+			f.sendEvents(batch.events)
+			continue
 		}
 
-		if stopping {
-			return nil
+		pending[batch.seq] = batch
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			f.sendEvents(b.events)
+			next++
+		}
+	}
+}
+
+func (f *FilterController) sendEvents(events []*event.Event) {
+	for _, event := range events {
+		if event != nil {
+			f.out <- event
 		}
 	}
 }
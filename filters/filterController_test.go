@@ -0,0 +1,75 @@
+package filters
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestFilterControllerFlush(t *testing.T) {
+	end, _ := New("end", nil)
+	controller := NewFilterController([]Filter{end}, 10, 20*time.Millisecond, false)
+
+	in := make(chan *event.Event)
+	out := make(chan *event.Event, 10)
+	controller.Start(in, out)
+	defer controller.Close()
+
+	in <- event.New("one")
+	in <- event.New("two")
+
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-out:
+			if e == nil {
+				t.Fatal("Received nil event")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Partial batch wasn't flushed, received %d events", i)
+		}
+	}
+}
+
+// slowFilter delays batches based on the first event's message
+// so later batches can finish before earlier ones.
+type slowFilter struct{}
+
+func (f *slowFilter) SetNext(next Filter) {}
+
+func (f *slowFilter) Run(batch []*event.Event) []*event.Event {
+	n, _ := strconv.Atoi(batch[0].GetMessage())
+	time.Sleep(time.Duration(10-n%10) * time.Millisecond)
+	return batch
+}
+
+func TestFilterControllerPreserveOrder(t *testing.T) {
+	pipelines := make([]Filter, 4)
+	for i := range pipelines {
+		pipelines[i] = &slowFilter{}
+	}
+	controller := NewFilterController(pipelines, 1, 0, true)
+
+	in := make(chan *event.Event)
+	out := make(chan *event.Event, 20)
+	controller.Start(in, out)
+
+	for i := 0; i < 20; i++ {
+		in <- event.New(strconv.Itoa(i))
+	}
+	controller.Close()
+	close(out)
+
+	i := 0
+	for e := range out {
+		if e.GetMessage() != strconv.Itoa(i) {
+			t.Fatalf("Event out of order. Expected %d, got %s", i, e.GetMessage())
+		}
+		i++
+	}
+
+	if i != 20 {
+		t.Fatalf("Incorrect event count. Expected 20, got %d", i)
+	}
+}
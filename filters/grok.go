@@ -6,6 +6,8 @@ import (
 	"regexp"
 
 	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/grok"
+	"github.com/lfkeitel/spartan/utils"
 )
 
 func init() {
@@ -13,8 +15,9 @@ func init() {
 }
 
 type grokConfig struct {
-	field string
-	regex *regexp.Regexp
+	field  string
+	regex  *regexp.Regexp
+	fields []string
 }
 
 // A GrokFilter processes event fields based on give regex patterns.
@@ -35,17 +38,32 @@ func newGrokFilter(options map[string]interface{}) (Filter, error) {
 
 func (f *GrokFilter) setConfig(options map[string]interface{}) error {
 	if s, exists := options["field"]; exists {
-		f.config.field = s.(string)
+		field, ok := s.(string)
+		if !ok {
+			return errors.New("Field must be a string")
+		}
+		f.config.field = field
 	} else {
 		f.config.field = "message"
 	}
 
 	if s, exists := options["regex"]; exists {
-		r, err := regexp.Compile(interpolatePatterns(s.(string)))
+		regex, ok := s.(string)
+		if !ok {
+			return errors.New("Regex must be a string")
+		}
+		r, err := grok.CompilePattern(regex)
 		if err != nil {
 			return fmt.Errorf("Regex failed to compile: %v", err)
 		}
 		f.config.regex = r
+
+		// Fields set by each group of the regex, unnamed groups are skipped
+		names := r.SubexpNames()
+		f.config.fields = make([]string, len(names))
+		for i, name := range names {
+			f.config.fields[i] = grok.GroupField(name)
+		}
 	} else {
 		return errors.New("Regex option required")
 	}
@@ -67,30 +85,30 @@ func (f *GrokFilter) Run(batch []*event.Event) []*event.Event {
 
 		field := event.Get(f.config.field)
 		if field == nil {
-			fmt.Printf("Field %s doesn't exist\n", f.config.field)
+			utils.Log.Debugf("Field %s doesn't exist", f.config.field)
 			event.AddTag("_grokparsefailure")
 			continue
 		}
 
 		fieldStr, ok := field.(string)
 		if !ok {
-			fmt.Printf("Field %s isn't a string\n", f.config.field)
+			utils.Log.Debugf("Field %s isn't a string", f.config.field)
 			event.AddTag("_grokparsefailure")
 			continue
 		}
 
 		matches := f.config.regex.FindAllStringSubmatch(fieldStr, -1)
 		if len(matches) == 0 {
-			fmt.Printf("No matches")
+			utils.Log.Debugf("No grok matches in field %s", f.config.field)
 			event.AddTag("_grokparsefailure")
 			continue
 		}
 
-		for i, group := range f.config.regex.SubexpNames() {
-			if i == 0 {
+		for i, field := range f.config.fields {
+			if i == 0 || field == "" {
 				continue
 			}
-			event.Set(group, matches[0][i])
+			event.Set(field, matches[0][i])
 		}
 	}
 
@@ -0,0 +1,29 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestGrokFilterFieldPaths(t *testing.T) {
+	f, err := newGrokFilter(map[string]interface{}{
+		"field": "[log][line]",
+		"regex": `%{IP:[client][ip]} (a|b) (?P<method>\w+) %{MONTH}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetNext(&End{})
+
+	e := event.New("")
+	e.Set("[log][line]", "10.0.0.1 a GET Mar")
+	f.Run([]*event.Event{e})
+
+	if e.Get("[client][ip]") != "10.0.0.1" || e.Get("method") != "GET" {
+		t.Errorf("Expected client ip 10.0.0.1 and method GET, got %#v and %#v", e.Get("[client][ip]"), e.Get("method"))
+	}
+	if e.HasField("") {
+		t.Error("Unnamed groups set as a field")
+	}
+}
@@ -12,10 +12,11 @@ func init() {
 	register("mutate", newMutateFilter)
 }
 
-var mutateActions = []string{"remove_field"}
+var mutateActions = []string{"remove_field", "add_field"}
 
 type mutateConfig struct {
 	fields []string
+	values map[string]string
 	action string
 }
 
@@ -36,21 +37,12 @@ func newMutateFilter(options map[string]interface{}) (Filter, error) {
 }
 
 func (f *MutateFilter) setConfig(options map[string]interface{}) error {
-	if s, exists := options["fields"]; exists {
-		switch s := s.(type) {
-		case string:
-			f.config.fields = []string{s}
-		case []string:
-			f.config.fields = s
-		default:
-			return errors.New("Fields must be a string or array of strings")
-		}
-	} else {
-		return errors.New("Fields option required")
-	}
-
 	if s, exists := options["action"]; exists {
-		f.config.action = s.(string)
+		action, ok := s.(string)
+		if !ok {
+			return errors.New("Action must be a string")
+		}
+		f.config.action = action
 		if !f.isValidAction(f.config.action) {
 			return fmt.Errorf("%s is not a valid mutate action", f.config.action)
 		}
@@ -58,6 +50,36 @@ func (f *MutateFilter) setConfig(options map[string]interface{}) error {
 		return errors.New("Action option required")
 	}
 
+	s, exists := options["fields"]
+	if !exists {
+		return errors.New("Fields option required")
+	}
+
+	if f.config.action == "add_field" {
+		m, ok := s.(*utils.InterfaceMap)
+		if !ok {
+			return errors.New("Fields must be a map of field names to values")
+		}
+		f.config.values = make(map[string]string, m.Len())
+		for field, val := range m.Map() {
+			str, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("Value of %s must be a string", field)
+			}
+			f.config.values[field] = str
+		}
+		return nil
+	}
+
+	switch s := s.(type) {
+	case string:
+		f.config.fields = []string{s}
+	case []string:
+		f.config.fields = s
+	default:
+		return errors.New("Fields must be a string or array of strings")
+	}
+
 	return nil
 }
 
@@ -73,9 +95,14 @@ func (f *MutateFilter) SetNext(next Filter) {
 // Run processes a batch.
 func (f *MutateFilter) Run(batch []*event.Event) []*event.Event {
 	for _, event := range batch {
+		if event == nil {
+			continue
+		}
 		switch f.config.action {
 		case "remove_field":
 			f.removeField(event)
+		case "add_field":
+			f.addField(event)
 		}
 	}
 	return f.next.Run(batch)
@@ -86,3 +113,11 @@ func (f *MutateFilter) removeField(e *event.Event) {
 		e.RemoveField(field)
 	}
 }
+
+// addField sets fields to their values. Field names and values may contain
+// %{field} references.
+func (f *MutateFilter) addField(e *event.Event) {
+	for field, val := range f.config.values {
+		e.Set(e.Sprintf(field), e.Sprintf(val))
+	}
+}
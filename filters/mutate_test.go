@@ -0,0 +1,40 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestMutateAddField(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	mutate {
+		action => "add_field"
+		fields => {
+			index => "logs-%{type}-%{+YYYY.MM}"
+			"%{type}_seen" => "yes"
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	pipeline, err := GeneratePipeline(pf.Filters)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	e := event.New("")
+	e.SetType("app")
+	batch := pipeline.Run([]*event.Event{e, nil})
+
+	expected := "logs-app-" + e.GetTimestamp().UTC().Format("2006.01")
+	if batch[0].Get("index") != expected {
+		t.Errorf("Expected index %s, got %#v", expected, batch[0].Get("index"))
+	}
+	if batch[0].Get("app_seen") != "yes" {
+		t.Errorf("Expected app_seen yes, got %#v", batch[0].Get("app_seen"))
+	}
+}
@@ -0,0 +1,89 @@
+package filters
+
+import (
+	"fmt"
+
+	"github.com/lfkeitel/spartan/config/parser"
+)
+
+// A workerChecker is a Filter that only works with some numbers of filter workers.
+type workerChecker interface {
+	// checkWorkers returns an error if the Filter can't be used with
+	// workers filter workers.
+	checkWorkers(workers int) error
+}
+
+// CheckWorkers returns an error for each filter in defs that can't be used with
+// workers filter workers. Invalid filters are skipped, GeneratePipeline
+// reports them.
+func CheckWorkers(defs []*parser.PipelineDef, workers int) []error {
+	var errs []error
+	for _, def := range defs {
+		if def.Condition != nil {
+			continue
+		}
+		filter, err := New(def.Module, def.Options.Map())
+		if err != nil {
+			continue
+		}
+		if c, ok := filter.(workerChecker); ok {
+			if err := c.checkWorkers(workers); err != nil {
+				errs = append(errs, fmt.Errorf("%s: filter %s: %v", def.Position(), def.Module, err))
+			}
+		}
+	}
+	return errs
+}
+
+// GeneratePipeline creates the Filter instances described by defs and connects
+// them as described by their Connections. The returned Filter is the root of the
+// pipeline. An empty defs slice results in a pipeline with only an End filter.
+func GeneratePipeline(defs []*parser.PipelineDef) (Filter, error) {
+	end, _ := New("end", nil)
+	if len(defs) == 0 {
+		return end, nil
+	}
+
+	filters := make([]Filter, len(defs))
+	for i, def := range defs {
+		if def.Condition != nil {
+			filters[i] = newConditional(def.Condition)
+			continue
+		}
+
+		filter, err := New(def.Module, def.Options.Map())
+		if err != nil {
+			return nil, fmt.Errorf("%s: filter %s: %v", def.Position(), def.Module, err)
+		}
+		filters[i] = filter
+	}
+
+	// filterAt returns the End filter for connections that don't exist
+	filterAt := func(i int) Filter {
+		if i < 0 {
+			return end
+		}
+		return filters[i]
+	}
+
+	for i, def := range defs {
+		switch len(def.Connections) {
+		case 0:
+			filters[i].SetNext(end)
+		case 1:
+			filters[i].SetNext(filterAt(def.Connections[0]))
+		case 3:
+			c, ok := filters[i].(*conditional)
+			if !ok {
+				return nil, fmt.Errorf("%s: filter %s: unsupported connections", def.Position(), def.Module)
+			}
+			c.ifTrue = filterAt(def.Connections[0])
+			c.ifFalse = filterAt(def.Connections[1])
+			c.SetNext(filterAt(def.Connections[2]))
+		default:
+			return nil, fmt.Errorf("%s: filter %s: unsupported connections", def.Position(), def.Module)
+		}
+	}
+
+	return filters[0], nil
+}
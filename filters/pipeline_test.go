@@ -0,0 +1,156 @@
+package filters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestGeneratePipeline(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	mutate {
+		action => "remove_field"
+		fields => ["field1"]
+	}
+	mutate {
+		action => "remove_field"
+		fields => ["field2"]
+	}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	pipeline, err := GeneratePipeline(pf.Filters)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	e := event.New("")
+	e.Set("field1", "value1")
+	e.Set("field2", "value2")
+	e.Set("field3", "value3")
+
+	batch := pipeline.Run([]*event.Event{e})
+	if len(batch) != 1 {
+		t.Fatalf("Incorrect batch len. Expected 1, got %d", len(batch))
+	}
+
+	if batch[0].Get("field1") != nil || batch[0].Get("field2") != nil {
+		t.Fatal("Fields not removed by pipeline")
+	}
+	if batch[0].Get("field3") != "value3" {
+		t.Fatal("Field3 was removed by pipeline")
+	}
+}
+
+func TestGeneratePipelineError(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	mutate {
+		action => "remove_field"
+		fields => ["field1"]
+	}
+
+	notafilter {}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	_, err = GeneratePipeline(pf.Filters)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "notafilter") || !strings.Contains(err.Error(), "line 7") {
+		t.Fatalf("Error doesn't name module and line: %s", err.Error())
+	}
+}
+
+func TestGenerateConditionalPipeline(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	if [type] == "dns" {
+		mutate {
+			action => "remove_field"
+			fields => ["field1"]
+		}
+	} else {
+		mutate {
+			action => "remove_field"
+			fields => ["field2"]
+		}
+	}
+
+	mutate {
+		action => "remove_field"
+		fields => ["field3"]
+	}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	pipeline, err := GeneratePipeline(pf.Filters)
+	if err != nil {
+		t.Fatalf("Unexpected error %s", err.Error())
+	}
+
+	dns := event.New("")
+	dns.SetType("dns")
+	other := event.New("")
+	for _, e := range []*event.Event{dns, other} {
+		e.Set("field1", "value1")
+		e.Set("field2", "value2")
+		e.Set("field3", "value3")
+	}
+
+	batch := pipeline.Run([]*event.Event{dns, other})
+	if len(batch) != 2 {
+		t.Fatalf("Incorrect batch len. Expected 2, got %d", len(batch))
+	}
+
+	if dns.Get("field1") != nil || dns.Get("field2") == nil {
+		t.Error("True branch not applied to dns event")
+	}
+	if other.Get("field1") == nil || other.Get("field2") != nil {
+		t.Error("False branch not applied to other event")
+	}
+	if dns.Get("field3") != nil || other.Get("field3") != nil {
+		t.Error("Branches didn't rejoin the pipeline")
+	}
+
+	// Events keep their order after the branches
+	other2 := event.New("")
+	dns2 := event.New("")
+	dns2.SetType("dns")
+	expected := []*event.Event{other, dns, other2, dns2}
+	batch = pipeline.Run(append([]*event.Event(nil), expected...))
+	if len(batch) != len(expected) {
+		t.Fatalf("Incorrect batch len. Expected %d, got %d", len(expected), len(batch))
+	}
+	for i := range expected {
+		if batch[i] != expected[i] {
+			t.Errorf("Branches changed the order of events, event %d moved", i+1)
+		}
+	}
+}
+
+func TestRejoin(t *testing.T) {
+	a, b, c, d := event.New("a"), event.New("b"), event.New("c"), event.New("d")
+	added := event.New("added")
+
+	// c was removed by a branch, added was created by one
+	joined := rejoin([]*event.Event{a, b, c, d}, []*event.Event{d, b}, []*event.Event{added, a, nil})
+
+	expected := []*event.Event{a, b, d, added}
+	if len(joined) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(joined))
+	}
+	for i := range expected {
+		if joined[i] != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i+1, expected[i].GetMessage(), joined[i].GetMessage())
+		}
+	}
+}
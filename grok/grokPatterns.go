@@ -2,7 +2,7 @@
 // To change a pattern, edit the correct file under patterns and run
 // "make generate" from the project root
 
-package filters
+package grok
 
 var grokPatterns = map[string]string{
 	"GROK_VARIABLE": `[a-zA-Z0-9_]+`,
@@ -1,10 +1,12 @@
-package filters
+// Package grok compiles regular expressions that reference grok patterns.
+package grok
 
 //go:generate go run ../cmd/patternsGen.go ../patterns grokPatterns.go
 //go:generate gofmt -w grokPatterns.go
 
 import (
 	"bufio"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,8 +21,13 @@ var (
 	varInterpolatePattern = fmt.Sprintf(`%%{(%s)(?:\:(.*?))?}`, grokPatterns["GROK_VARIABLE"])
 	varInterpolateRegex   = regexp.MustCompile(varInterpolatePattern)
 	varPattern            = regexp.MustCompile(grokPatterns["GROK_VARIABLE"])
+	groupNameRegex        = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
 )
 
+// encodedGroupPrefix starts the regex group names of fields that can't be used
+// as a group name.
+const encodedGroupPrefix = "_field_"
+
 func interpolatePatterns(s string) string {
 	matches := varInterpolateRegex.FindAllStringSubmatch(s, -1)
 	if len(matches) == 0 {
@@ -30,7 +37,7 @@ func interpolatePatterns(s string) string {
 	for _, match := range matches {
 		var r string
 		if match[2] != "" {
-			r = fmt.Sprintf(`(?P<%s>%s)`, match[2], grokPatterns[match[1]])
+			r = fmt.Sprintf(`(?P<%s>%s)`, groupName(match[2]), grokPatterns[match[1]])
 		} else {
 			r = grokPatterns[match[1]]
 		}
@@ -40,6 +47,63 @@ func interpolatePatterns(s string) string {
 	return interpolatePatterns(s)
 }
 
+// groupName returns the regex group name for field. Fields such as [a][b]
+// that aren't valid group names are hex encoded.
+func groupName(field string) string {
+	if groupNameRegex.MatchString(field) && !strings.HasPrefix(field, encodedGroupPrefix) {
+		return field
+	}
+	return encodedGroupPrefix + hex.EncodeToString([]byte(field))
+}
+
+// GroupField returns the field set by the regex group name of a pattern
+// compiled by CompilePattern. Fields such as [a][b] are encoded in group names.
+func GroupField(name string) string {
+	if !strings.HasPrefix(name, encodedGroupPrefix) {
+		return name
+	}
+	field, err := hex.DecodeString(name[len(encodedGroupPrefix):])
+	if err != nil {
+		return name
+	}
+	return string(field)
+}
+
+// CompilePattern compiles the regular expression s after replacing references
+// to grok patterns in the form %{NAME} or %{NAME:field} with the pattern.
+func CompilePattern(s string) (*regexp.Regexp, error) {
+	if err := checkPatterns(s); err != nil {
+		return nil, err
+	}
+	return regexp.Compile(interpolatePatterns(s))
+}
+
+// checkPatterns ensures all patterns referenced in s, and the patterns
+// they reference, are defined.
+func checkPatterns(s string) error {
+	return checkPatternRefs(s, make(map[string]bool))
+}
+
+func checkPatternRefs(s string, checked map[string]bool) error {
+	for _, match := range varInterpolateRegex.FindAllStringSubmatch(s, -1) {
+		name := match[1]
+		if checked[name] {
+			continue
+		}
+
+		pattern, exists := grokPatterns[name]
+		if !exists {
+			return fmt.Errorf("Pattern %s is not defined", name)
+		}
+
+		checked[name] = true
+		if err := checkPatternRefs(pattern, checked); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // LoadPatterns will load pattern files from the path.
 // Path may be a directory or file. If a directory,
 // all files with a PatternExt extension will be loaded in.
@@ -49,7 +113,7 @@ func LoadPatterns(path string) error {
 }
 
 func walkPatternDir(path string, info os.FileInfo, err error) error {
-	if info.IsDir() || err != nil {
+	if err != nil || info.IsDir() {
 		return err
 	}
 
@@ -1,6 +1,9 @@
-package filters
+package grok
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+)
 
 func TestPatternInterpolation(t *testing.T) {
 	tests := []struct{ start, end string }{
@@ -33,3 +36,43 @@ func TestPatternInterpolation(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckPatterns(t *testing.T) {
+	tests := []struct {
+		regex string
+		valid bool
+	}{
+		{"Hello", true},
+		{"%{TIME} %{IP:clientip}", true},
+		{"%{NOTAPATTERN}", false},
+		{"%{HOUR} %{NOTAPATTERN:name}", false},
+	}
+
+	for i, test := range tests {
+		err := checkPatterns(test.regex)
+		if test.valid && err != nil {
+			t.Errorf("Pattern check test %d. Unexpected error %s", i+1, err.Error())
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Pattern check test %d. Expected error, got nil", i+1)
+		}
+	}
+}
+
+func TestGroupName(t *testing.T) {
+	for _, field := range []string{"ip", "[client][ip]", "_field_ab", "a.b"} {
+		name := groupName(field)
+		if !groupNameRegex.MatchString(name) {
+			t.Errorf("%s: invalid group name %s", field, name)
+		}
+		if GroupField(name) != field {
+			t.Errorf("%s: group name %s decoded as %s", field, name, GroupField(name))
+		}
+	}
+}
+
+func TestLoadPatternsMissing(t *testing.T) {
+	if err := LoadPatterns(filepath.Join("testdata", "missing")); err == nil {
+		t.Error("Expected error loading a missing directory")
+	}
+}
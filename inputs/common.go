@@ -0,0 +1,127 @@
+package inputs
+
+import (
+	"errors"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+// commonConfig contains the options accepted by every Input.
+type commonConfig struct {
+	etype    string
+	tags     []string
+	addField map[string]interface{}
+}
+
+// splitCommonOptions removes the options common to all Inputs from options.
+// The parsed common options are returned along with the remaining options.
+// If no common options are set, the returned commonConfig is nil.
+func splitCommonOptions(options map[string]interface{}) (*commonConfig, map[string]interface{}, error) {
+	var c *commonConfig
+	rest := make(map[string]interface{}, len(options))
+
+	for key, val := range options {
+		if key != "type" && key != "tags" && key != "add_field" {
+			rest[key] = val
+			continue
+		}
+		if c == nil {
+			c = &commonConfig{}
+		}
+
+		switch key {
+		case "type":
+			etype, ok := val.(string)
+			if !ok {
+				return nil, nil, errors.New("type must be a string")
+			}
+			c.etype = etype
+		case "tags":
+			tags, ok := stringSlice(val)
+			if !ok {
+				return nil, nil, errors.New("tags must be a string or array of strings")
+			}
+			c.tags = tags
+		case "add_field":
+			fields, ok := val.(*utils.InterfaceMap)
+			if !ok {
+				return nil, nil, errors.New("add_field must be a map")
+			}
+			c.addField = fields.Map()
+		}
+	}
+
+	return c, rest, nil
+}
+
+// apply sets the common fields on e. The type is only set if the Input
+// didn't set one, such as a type decoded by its codec. Added field names and string values may contain %{field}
+// references. Each Event gets its own copy of added maps and arrays so
+// filters can change them.
+func (c *commonConfig) apply(e *event.Event) {
+	if c.etype != "" && e.GetType() == "" {
+		e.SetType(c.etype)
+	}
+	for _, tag := range c.tags {
+		e.AddTag(tag)
+	}
+	for key, val := range c.addField {
+		if s, ok := val.(string); ok {
+			e.Set(e.Sprintf(key), e.Sprintf(s))
+		} else {
+			e.Set(e.Sprintf(key), utils.DeepCopy(val))
+		}
+	}
+}
+
+// A commonInput wraps an Input to apply the common options to its Events.
+type commonInput struct {
+	input  Input
+	config *commonConfig
+	t      tomb.Tomb
+	in     chan *event.Event
+	out    chan<- *event.Event
+}
+
+func (i *commonInput) Start(out chan<- *event.Event) error {
+	i.in = make(chan *event.Event)
+	i.out = out
+	if err := i.input.Start(i.in); err != nil {
+		return err
+	}
+	i.t.Go(i.run)
+	return nil
+}
+
+// Close shuts down the wrapped Input before the go routine forwarding its Events.
+func (i *commonInput) Close() error {
+	err := i.input.Close()
+	i.t.Kill(nil)
+	i.t.Wait()
+	return err
+}
+
+// Done returns the wrapped Input's Done channel if it's a Finisher.
+func (i *commonInput) Done() <-chan struct{} {
+	if f, ok := i.input.(Finisher); ok {
+		return f.Done()
+	}
+	return nil
+}
+
+func (i *commonInput) run() error {
+	for {
+		select {
+		case e := <-i.in:
+			if e == nil {
+				continue
+			}
+			i.config.apply(e)
+			i.out <- e
+		case <-i.t.Dying():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package inputs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestCommonOptions(t *testing.T) {
+	pf, err := parser.ParseString(`input {
+	stdin {
+		type => "app"
+		tags => ["a", "b"]
+		add_field => {
+			env => "prod"
+			source => "%{type}-%{host}"
+			ports => [80, 443]
+			owner => { team => "ops" }
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	common, rest, err := splitCommonOptions(pf.Inputs[0].Options.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("Expected no remaining options, got %v", rest)
+	}
+
+	e1 := event.New("one")
+	e1.Set("host", "web1")
+	e2 := event.New("two")
+	e2.SetType("input")
+	common.apply(e1)
+	common.apply(e2)
+
+	if e1.GetType() != "app" || e2.GetType() != "input" {
+		t.Errorf("Expected types app and input, got %q and %q", e1.GetType(), e2.GetType())
+	}
+	if !reflect.DeepEqual(e1.GetTags(), []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b], got %v", e1.GetTags())
+	}
+	if e1.Get("env") != "prod" || e1.Get("[owner][team]") != "ops" {
+		t.Errorf("Expected env prod and owner team ops, got %v and %v", e1.Get("env"), e1.Get("[owner][team]"))
+	}
+	if e1.Get("source") != "app-web1" {
+		t.Errorf("Expected source app-web1, got %v", e1.Get("source"))
+	}
+
+	// Events don't share added maps and arrays
+	e1.Set("[owner][team]", "dev")
+	e1.Get("ports").([]int)[0] = 8080
+	if e2.Get("[owner][team]") != "ops" || e2.Get("ports").([]int)[0] != 80 {
+		t.Error("Added fields are shared between events")
+	}
+	if common.addField["ports"].([]int)[0] != 80 {
+		t.Error("Configured add_field was changed")
+	}
+
+	invalid := []map[string]interface{}{
+		{"type": 1},
+		{"tags": 1},
+		{"add_field": "env"},
+	}
+	for _, options := range invalid {
+		if _, _, err := splitCommonOptions(options); err == nil {
+			t.Errorf("Expected error for options %v", options)
+		}
+	}
+}
+
+func TestCommonOptionsDecodedType(t *testing.T) {
+	common, _, err := splitCommonOptions(map[string]interface{}{"type": "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codec, _ := codecs.New("json", nil)
+	decoded, err := codec.Decode([]byte(`{"message": "a", "type": "decoded"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	untyped, err := codec.Decode([]byte(`{"message": "b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	common.apply(decoded)
+	common.apply(untyped)
+
+	if decoded.GetType() != "decoded" || untyped.GetType() != "app" {
+		t.Errorf("Expected types decoded and app, got %q and %q", decoded.GetType(), untyped.GetType())
+	}
+}
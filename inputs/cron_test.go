@@ -0,0 +1,61 @@
+package inputs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2017, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2017, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2017, time.March, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2017, time.March, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", time.Date(2017, time.March, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2017, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * *", time.Date(2017, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * sat", time.Date(2017, time.March, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2017, time.March, 15, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		s, err := parseCron(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if next := s.next(from); !next.Equal(test.expected) {
+			t.Errorf("%q: expected %s, got %s", test.spec, test.expected, next)
+		}
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"0 0 30 feb *",
+	}
+
+	for _, spec := range invalid {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package inputs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("exec", newExecInput)
+}
+
+type execConfig struct {
+	command    string
+	args       []string
+	interval   time.Duration
+	schedule   *cronSchedule
+	timeout    time.Duration
+	splitLines bool
+}
+
+// An ExecInput runs a command every interval, or at times given by a cron style
+// schedule, and creates events from its standard output. A command given as a
+// string is run by the shell, an array is run directly. The whole output is the
+// message of one event, or with split_lines each non-empty line is an event.
+// Events have the fields command, exit_code, duration (in seconds), and host set.
+//
+// A run that's still going when the next is due delays the next run. If timeout
+// is set the command is killed after running that long, its exit_code is -1 and
+// events are tagged _exectimeout.
+type ExecInput struct {
+	config *execConfig
+	host   string
+	t      tomb.Tomb
+	out    chan<- *event.Event
+}
+
+func newExecInput(options map[string]interface{}) (Input, error) {
+	i := &ExecInput{
+		config: &execConfig{},
+		host:   hostname(),
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *ExecInput) setConfig(options map[string]interface{}) error {
+	if s, exists := options["command"]; exists {
+		switch s := s.(type) {
+		case string:
+			if s == "" {
+				return errors.New("command must not be empty")
+			}
+			i.config.command = s
+		case []string:
+			if len(s) == 0 || s[0] == "" {
+				return errors.New("command must not be empty")
+			}
+			i.config.command = strings.Join(s, " ")
+			i.config.args = s
+		default:
+			return errors.New("command must be a string or array of strings")
+		}
+	} else {
+		return errors.New("command option required")
+	}
+
+	if s, exists := options["interval"]; exists {
+		interval, err := durationOption(s)
+		if err != nil {
+			return errors.New("interval must be a duration string such as \"60s\"")
+		}
+		i.config.interval = interval
+	}
+
+	if s, exists := options["schedule"]; exists {
+		spec, ok := s.(string)
+		if !ok {
+			return errors.New("schedule must be a string")
+		}
+		schedule, err := parseCron(spec)
+		if err != nil {
+			return err
+		}
+		i.config.schedule = schedule
+	}
+
+	if (i.config.interval == 0) == (i.config.schedule == nil) {
+		return errors.New("Exactly one of interval or schedule is required")
+	}
+
+	if s, exists := options["timeout"]; exists {
+		timeout, err := durationOption(s)
+		if err != nil {
+			return errors.New("timeout must be a duration string such as \"10s\"")
+		}
+		i.config.timeout = timeout
+	}
+
+	if s, exists := options["split_lines"]; exists {
+		split, ok := s.(bool)
+		if !ok {
+			return errors.New("split_lines must be a boolean")
+		}
+		i.config.splitLines = split
+	}
+
+	return nil
+}
+
+// durationOption parses a positive duration string.
+func durationOption(s interface{}) (time.Duration, error) {
+	str, ok := s.(string)
+	if !ok {
+		return 0, errors.New("not a string")
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.New("not positive")
+	}
+	return d, nil
+}
+
+// Start running the command.
+func (i *ExecInput) Start(out chan<- *event.Event) error {
+	i.out = out
+	i.t.Go(i.run)
+	return nil
+}
+
+// Close stops running the command. A running command is killed.
+func (i *ExecInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+func (i *ExecInput) run() error {
+	// Intervals start immediately, schedules wait for the first match
+	next := time.Now()
+	if i.config.schedule != nil {
+		next = i.config.schedule.next(next)
+	}
+
+	for {
+		if next.IsZero() {
+			utils.Log.Errorf("Exec input: schedule for %q has no more runs", i.config.command)
+			<-i.t.Dying()
+			return nil
+		}
+
+		timer := time.NewTimer(next.Sub(time.Now()))
+		select {
+		case <-timer.C:
+		case <-i.t.Dying():
+			timer.Stop()
+			return nil
+		}
+
+		for _, e := range i.execute() {
+			select {
+			case i.out <- e:
+			case <-i.t.Dying():
+				return nil
+			}
+		}
+
+		if i.config.schedule != nil {
+			next = i.config.schedule.next(time.Now())
+		} else {
+			next = next.Add(i.config.interval)
+			if now := time.Now(); next.Before(now) {
+				next = now
+			}
+		}
+	}
+}
+
+// execute runs the command once and returns the events created from its output.
+func (i *ExecInput) execute() []*event.Event {
+	ctx := i.t.Context(nil)
+	if i.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.config.timeout)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	switch {
+	case i.config.args != nil:
+		cmd = exec.Command(i.config.args[0], i.config.args[1:]...)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("cmd", "/C", i.config.command)
+	default:
+		cmd = exec.Command("/bin/sh", "-c", i.config.command)
+	}
+	setProcessGroup(cmd)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		utils.Log.Errorf("Exec input: %q: %v", i.config.command, err)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcess(cmd)
+		err = <-done
+	}
+	duration := time.Since(start)
+
+	exitCode := 0
+	timedOut := false
+	if err != nil {
+		select {
+		case <-i.t.Dying():
+			return nil
+		default:
+		}
+
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			utils.Log.Errorf("Exec input: %q: %v", i.config.command, err)
+			return nil
+		}
+
+		if ctx.Err() == context.DeadlineExceeded {
+			utils.Log.Warningf("Exec input: %q timed out after %s", i.config.command, i.config.timeout)
+			exitCode = -1
+			timedOut = true
+		} else if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			exitCode = status.ExitStatus()
+		} else {
+			exitCode = -1
+		}
+	}
+
+	if stderr.Len() > 0 {
+		utils.Log.Debugf("Exec input: %q: %s", i.config.command, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var messages []string
+	if i.config.splitLines {
+		messages = splitLines(stdout.String())
+	} else {
+		messages = []string{strings.TrimRight(stdout.String(), "\r\n")}
+	}
+
+	events := make([]*event.Event, len(messages))
+	for n, message := range messages {
+		e := event.New(message)
+		e.Set("command", i.config.command)
+		e.Set("exit_code", exitCode)
+		e.Set("duration", duration.Seconds())
+		e.Set("host", i.host)
+		if timedOut {
+			e.AddTag("_exectimeout")
+		}
+		events[n] = e
+	}
+	return events
+}
+
+// splitLines returns the non-empty lines in s.
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,68 @@
+package inputs
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestExecInputExecute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Test uses a POSIX shell")
+	}
+
+	input, err := newExecInput(map[string]interface{}{
+		"command":     "echo one; echo; echo two; exit 3",
+		"interval":    "60s",
+		"split_lines": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := input.(*ExecInput).execute()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	for n, message := range []string{"one", "two"} {
+		e := events[n]
+		if e.GetMessage() != message {
+			t.Errorf("Expected message %q, got %q", message, e.GetMessage())
+		}
+		if e.Get("exit_code") != 3 {
+			t.Errorf("Expected exit_code 3, got %v", e.Get("exit_code"))
+		}
+	}
+
+	input, _ = newExecInput(map[string]interface{}{
+		"command":  "sleep 5",
+		"interval": "60s",
+		"timeout":  "50ms",
+	})
+	events = input.(*ExecInput).execute()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].Get("exit_code") != -1 {
+		t.Errorf("Expected exit_code -1, got %v", events[0].Get("exit_code"))
+	}
+	if tags := events[0].Get("tags").([]string); len(tags) != 1 || tags[0] != "_exectimeout" {
+		t.Errorf("Expected _exectimeout tag, got %v", tags)
+	}
+}
+
+func TestExecInputOptions(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{"interval": "60s"},
+		{"command": "", "interval": "60s"},
+		{"command": "true"},
+		{"command": "true", "interval": "60s", "schedule": "* * * * *"},
+		{"command": "true", "interval": "soon"},
+		{"command": "true", "schedule": "* * *"},
+		{"command": "true", "interval": "60s", "split_lines": "yes"},
+	}
+	for _, options := range invalid {
+		if _, err := newExecInput(options); err == nil {
+			t.Errorf("Expected error for options %v", options)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package inputs
+
+import "os/exec"
+
+// setProcessGroup isn't supported on Windows, only the command
+// itself is killed.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcess kills cmd.
+func killProcess(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
@@ -3,10 +3,16 @@ package inputs
 import (
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/hpcloud/tail"
+	"github.com/lfkeitel/spartan/codecs"
 	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
 	"gopkg.in/tomb.v2"
 )
 
@@ -14,32 +20,107 @@ func init() {
 	register("file", newFileInput)
 }
 
+// sincedbInterval is how often read positions are written to the sincedb.
+const sincedbInterval = 15 * time.Second
+
+// discoverInterval is how often paths are searched for new files.
+var discoverInterval = 5 * time.Second
+
 type fileConfig struct {
-	path string
+	paths         []string
+	exclude       []string
+	startPosition string
+	sincedbPath   string
+	multiline     map[string]interface{}
 }
 
-// A FileInput will read a file and optionally tail it. Each line is considered
-// a separate event.
+// A FileInput will read files and tail them. Each line is considered a separate
+// event. Paths may be glob patterns and are periodically searched for new files.
+// Each file is tailed in its own go routine until it's removed. The read position
+// of each file is saved to a sincedb so reading resumes where it left off after a
+// restart. Files without a saved position found when the input starts are read
+// from the start position, "beginning" or "end". Files created later are always
+// read from the beginning. Events have the fields path, host, and offset set to the
+// file, the local hostname, and the byte offset of the line in the file. If the
+// multiline option is set, lines are combined into events with a MultilineCodec
+// configured by the option's map.
 type FileInput struct {
-	config *fileConfig
-	t      tomb.Tomb
-	out    chan<- *event.Event
+	config  *fileConfig
+	host    string
+	t       tomb.Tomb
+	out     chan<- *event.Event
+	db      *sincedb
+	mu      sync.Mutex
+	tailing map[string]bool
+	tails   sync.WaitGroup
 }
 
 func newFileInput(options map[string]interface{}) (Input, error) {
 	i := &FileInput{
-		config: &fileConfig{},
+		config: &fileConfig{
+			startPosition: "end",
+		},
+		host:    hostname(),
+		tailing: make(map[string]bool),
 	}
 	return i, i.setConfig(options)
 }
 
 func (i *FileInput) setConfig(options map[string]interface{}) error {
 	if s, exists := options["path"]; exists {
-		i.config.path = s.(string)
+		paths, ok := stringSlice(s)
+		if !ok || len(paths) == 0 {
+			return errors.New("Path must be a string or array of strings")
+		}
+		if err := checkGlobs(paths); err != nil {
+			return fmt.Errorf("Path: %v", err)
+		}
+		i.config.paths = paths
 	} else {
 		return errors.New("Path option required")
 	}
 
+	if s, exists := options["exclude"]; exists {
+		exclude, ok := stringSlice(s)
+		if !ok {
+			return errors.New("exclude must be a string or array of strings")
+		}
+		if err := checkGlobs(exclude); err != nil {
+			return fmt.Errorf("exclude: %v", err)
+		}
+		i.config.exclude = exclude
+	}
+
+	if s, exists := options["start_position"]; exists {
+		pos, ok := s.(string)
+		if !ok || (pos != "beginning" && pos != "end") {
+			return errors.New("start_position must be \"beginning\" or \"end\"")
+		}
+		i.config.startPosition = pos
+	}
+
+	if s, exists := options["sincedb_path"]; exists {
+		path, ok := s.(string)
+		if !ok {
+			return errors.New("sincedb_path must be a string")
+		}
+		if !fileIDSupported {
+			return errors.New("sincedb_path isn't supported on this platform, read positions aren't saved")
+		}
+		i.config.sincedbPath = path
+	}
+
+	if s, exists := options["multiline"]; exists {
+		m, ok := s.(*utils.InterfaceMap)
+		if !ok {
+			return errors.New("multiline must be a map")
+		}
+		if _, err := codecs.NewMultilineCodec(m.Map()); err != nil {
+			return fmt.Errorf("multiline: %v", err)
+		}
+		i.config.multiline = m.Map()
+	}
+
 	return nil
 }
 
@@ -57,39 +138,318 @@ func (i *FileInput) Close() error {
 }
 
 func (i *FileInput) run() error {
+	if !fileIDSupported {
+		utils.Log.Warningf("File input: read positions can't be saved on this platform, files are read from start_position after every restart")
+	}
+
+	sincedbPath := i.config.sincedbPath
+	if sincedbPath == "" {
+		sincedbPath = defaultSincedbPath(i.config.paths)
+	}
+
+	var err error
+	i.db, err = openSincedb(sincedbPath)
+	if err != nil {
+		utils.Log.Errorf("File input: %v", err)
+	}
+
+	i.discover(i.config.startPosition)
+
+	discoverTicker := time.NewTicker(discoverInterval)
+	defer discoverTicker.Stop()
+	saveTicker := time.NewTicker(sincedbInterval)
+	defer saveTicker.Stop()
+
 	for {
 		select {
+		case <-discoverTicker.C:
+			i.discover("beginning")
+		case <-saveTicker.C:
+			if err := i.db.save(); err != nil {
+				utils.Log.Errorf("File input: %v", err)
+			}
 		case <-i.t.Dying():
+			// Positions are final once all files have stopped
+			i.tails.Wait()
+			if err := i.db.save(); err != nil {
+				utils.Log.Errorf("File input: %v", err)
+			}
 			return nil
-		default:
 		}
+	}
+}
+
+// discover starts tailing files matching the configured paths that aren't
+// already being tailed. New files are read from startPosition. Positions
+// of files that no longer match are removed from the sincedb.
+func (i *FileInput) discover(startPosition string) {
+	matched := make(map[fileID]bool)
+
+	for _, pattern := range i.config.paths {
+		paths, _ := filepath.Glob(pattern) // Patterns are checked in setConfig
+		for _, path := range paths {
+			if i.excluded(path) {
+				continue
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if id, ok := getFileID(info); ok {
+				matched[id] = true
+			}
 
-		t, err := tail.TailFile(i.config.path, tail.Config{
-			Follow: true,
-			ReOpen: true,
-		})
+			i.mu.Lock()
+			tailing := i.tailing[path]
+			i.tailing[path] = true
+			i.mu.Unlock()
+
+			if tailing {
+				continue
+			}
 
-		if err != nil {
-			fmt.Println(err.Error())
-			time.Sleep(500 * time.Millisecond)
-			continue
+			utils.Log.Infof("File input: reading %s", path)
+			i.tails.Add(1)
+			path := path
+			i.t.Go(func() error {
+				i.tailFile(path, startPosition)
+				i.mu.Lock()
+				delete(i.tailing, path)
+				i.mu.Unlock()
+				i.tails.Done()
+				return nil
+			})
 		}
+	}
 
-		var line *tail.Line
+	i.db.retain(matched)
+}
 
-		for {
-			select {
-			case line = <-t.Lines:
-				if line.Err != nil {
-					fmt.Println(line.Err.Error())
-					continue
+// excluded returns if the file name of path matches an exclude pattern.
+func (i *FileInput) excluded(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range i.config.exclude {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
+
+// tailFile follows the file at path until the input is closed or the file is
+// removed. The position of each event sent is recorded in the sincedb.
+func (i *FileInput) tailFile(path, startPosition string) {
+	pos := &filePosition{path: path}
+	location := pos.start(i.db, startPosition)
+
+	t, err := tail.TailFile(path, tail.Config{
+		Follow:    true,
+		MustExist: true,
+		Location:  location,
+		Logger:    log.New(tailLog{}, "", 0),
+	})
+	if err != nil {
+		utils.Log.Errorf("File input: %v", err)
+		return
+	}
+	defer t.Cleanup()
+
+	var multiline *codecs.MultilineCodec
+	if i.config.multiline != nil {
+		multiline, _ = codecs.NewMultilineCodec(i.config.multiline) // Checked in setConfig
+	}
+
+	// flushTimer fires when held multiline lines have waited long enough
+	flushTimer := time.NewTimer(time.Hour)
+	flushTimer.Stop()
+	defer flushTimer.Stop()
+
+	// flush sends any held multiline lines as an event
+	flush := func() {
+		if multiline == nil {
+			return
+		}
+		if e := multiline.Flush(); e != nil {
+			i.send(e, path, pos.offset)
+			pos.commit(i.db, pos.read)
+		}
+	}
+
+	for {
+		select {
+		case line, ok := <-t.Lines:
+			if !ok {
+				flush()
+				if err := t.Err(); err != nil {
+					utils.Log.Errorf("File input: %v", err)
 				}
-				i.out <- event.New(line.Text)
-			case <-i.t.Dying():
-				t.Stop()
-				t.Cleanup()
-				return nil
+				utils.Log.Infof("File input: stopped reading %s", path)
+				return
+			}
+			if line.Err != nil {
+				utils.Log.Errorf("File input: %v", line.Err)
+				continue
+			}
+
+			if pos.truncated() {
+				// Tailing restarted from the beginning of the file
+				flush()
+				pos.reset(i.db)
 			}
+
+			lineStart := pos.read
+			pos.read += int64(len(line.Text)) + 1
+
+			if multiline == nil {
+				i.send(event.New(line.Text), path, lineStart)
+				pos.commit(i.db, pos.read)
+				continue
+			}
+
+			// Held lines start at the committed offset
+			eventStart := pos.offset
+			e, _ := multiline.Decode([]byte(line.Text))
+			if e != nil {
+				i.send(e, path, eventStart)
+			}
+
+			if multiline.Buffered() == 0 {
+				pos.commit(i.db, pos.read)
+			} else {
+				if e != nil {
+					pos.commit(i.db, lineStart)
+				}
+				if interval := multiline.FlushInterval(); interval > 0 {
+					flushTimer.Stop()
+					select {
+					case <-flushTimer.C:
+					default:
+					}
+					flushTimer.Reset(interval)
+				}
+			}
+		case <-flushTimer.C:
+			flush()
+		case <-i.t.Dying():
+			// Lines read but not sent are discarded, they weren't
+			// counted in the position and will be read again.
+			t.Kill(nil)
+			for range t.Lines {
+			}
+			t.Wait()
+			flush()
+			return
+		}
+	}
+}
+
+// send sets the source fields on e and sends it to the pipeline. offset is
+// where the event starts in the file.
+func (i *FileInput) send(e *event.Event, path string, offset int64) {
+	e.Set("path", path)
+	e.Set("host", i.host)
+	e.Set("offset", offset)
+	i.out <- e
+}
+
+// A filePosition tracks the offsets of the file currently open at path.
+// read is the offset after the last line read. offset is the position
+// recorded in the sincedb, the start of the first line not yet sent.
+type filePosition struct {
+	path   string
+	id     fileID
+	known  bool // id is valid
+	offset int64
+	read   int64
+}
+
+// start identifies the file at path and returns where tailing should begin.
+// A nil location means the beginning of the file.
+func (p *filePosition) start(db *sincedb, startPosition string) *tail.SeekInfo {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil
+	}
+	p.id, p.known = getFileID(info)
+
+	if p.known {
+		if offset, exists := db.get(p.id); exists && offset <= info.Size() {
+			p.offset, p.read = offset, offset
+			return &tail.SeekInfo{Offset: p.offset, Whence: os.SEEK_SET}
+		}
+	}
+
+	if startPosition == "end" {
+		p.offset, p.read = info.Size(), info.Size()
+		p.save(db)
+		return &tail.SeekInfo{Offset: p.offset, Whence: os.SEEK_SET}
+	}
+	return nil
+}
+
+// commit records offset as the position reading should resume from.
+func (p *filePosition) commit(db *sincedb, offset int64) {
+	if !p.known {
+		// The file didn't exist when tailing started
+		if info, err := os.Stat(p.path); err == nil {
+			p.id, p.known = getFileID(info)
 		}
 	}
+	p.offset = offset
+	p.save(db)
+}
+
+// truncated returns if the file at path was truncated to less than the offset
+// already read. A file replaced by a different file isn't truncated, the old
+// file is still being read.
+func (p *filePosition) truncated() bool {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return false
+	}
+	if id, ok := getFileID(info); ok && p.known && id != p.id {
+		return false
+	}
+	return info.Size() < p.read
+}
+
+// reset starts tracking a truncated file from its beginning. If the file
+// was replaced, the position of the old file is forgotten.
+func (p *filePosition) reset(db *sincedb) {
+	oldID, wasKnown := p.id, p.known
+	p.id, p.known = fileID{}, false
+	if info, err := os.Stat(p.path); err == nil {
+		p.id, p.known = getFileID(info)
+	}
+	if wasKnown && (!p.known || oldID != p.id) {
+		db.remove(oldID)
+	}
+	p.offset, p.read = 0, 0
+	p.save(db)
+}
+
+func (p *filePosition) save(db *sincedb) {
+	if p.known {
+		db.set(p.id, p.offset, p.path)
+	}
+}
+
+// tailLog receives log messages from the tail library and logs them at the
+// debug level.
+type tailLog struct{}
+
+func (tailLog) Write(p []byte) (int, error) {
+	utils.Log.Debugf("File input: %s", p)
+	return len(p), nil
+}
+
+// checkGlobs returns an error if any pattern is malformed.
+func checkGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: %v", pattern, err)
+		}
+	}
+	return nil
 }
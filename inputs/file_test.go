@@ -0,0 +1,271 @@
+//go:build !windows
+// +build !windows
+
+package inputs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+// fileTest runs a file input reading path until the events expected have
+// been received. The messages and offsets of the events are checked.
+func fileTest(t *testing.T, options map[string]interface{}, write func(), expected map[string]int64) {
+	input, err := newFileInput(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := make(chan *event.Event, 10)
+	input.Start(out)
+	defer input.Close()
+
+	if write != nil {
+		time.Sleep(100 * time.Millisecond) // Let tailing start
+		write()
+	}
+
+	for len(expected) > 0 {
+		select {
+		case e := <-out:
+			offset, exists := expected[e.GetMessage()]
+			if !exists {
+				t.Fatalf("Unexpected event %q", e.GetMessage())
+			}
+			if e.Get("offset") != offset {
+				t.Errorf("%s: expected offset %d, got %v", e.GetMessage(), offset, e.Get("offset"))
+			}
+			if e.Get("path") != options["path"] || e.Get("host") != hostname() {
+				t.Errorf("%s: unexpected path %v or host %v", e.GetMessage(), e.Get("path"), e.Get("host"))
+			}
+			delete(expected, e.GetMessage())
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Events not received: %v", expected)
+		}
+	}
+}
+
+func appendTestFile(t *testing.T, path, data string) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(data)
+	file.Close()
+}
+
+func TestFileInputResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.log")
+	appendTestFile(t, path, "one\ntwo\n")
+	options := map[string]interface{}{
+		"path":           path,
+		"start_position": "beginning",
+		"sincedb_path":   filepath.Join(dir, "sincedb"),
+	}
+
+	fileTest(t, options, nil, map[string]int64{"one": 0, "two": 4})
+
+	// The saved position is used instead of start_position
+	appendTestFile(t, path, "three\n")
+	fileTest(t, options, nil, map[string]int64{"three": 8})
+
+	options["start_position"] = "end"
+	appendTestFile(t, path, "four\n")
+	fileTest(t, options, nil, map[string]int64{"four": 14})
+}
+
+func TestFileInputStartEnd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.log")
+	appendTestFile(t, path, "old\n")
+	options := map[string]interface{}{
+		"path":         path,
+		"sincedb_path": filepath.Join(dir, "sincedb"),
+	}
+
+	fileTest(t, options, func() { appendTestFile(t, path, "new\n") }, map[string]int64{"new": 4})
+
+	// Lines written while stopped are read after a restart
+	appendTestFile(t, path, "stopped\n")
+	fileTest(t, options, nil, map[string]int64{"stopped": 8})
+}
+
+func TestFileInputTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.log")
+	appendTestFile(t, path, "first line\nsecond line\n")
+	options := map[string]interface{}{
+		"path":           path,
+		"start_position": "beginning",
+		"sincedb_path":   filepath.Join(dir, "sincedb"),
+	}
+
+	fileTest(t, options, func() {
+		time.Sleep(100 * time.Millisecond)
+		ioutil.WriteFile(path, []byte("a\nb\n"), 0644)
+	}, map[string]int64{"first line": 0, "second line": 11, "a": 0, "b": 2})
+
+	db, err := openSincedb(filepath.Join(dir, "sincedb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	id, _ := getFileID(info)
+	if offset, _ := db.get(id); offset != 4 {
+		t.Errorf("Expected saved offset 4 after truncation, got %d", offset)
+	}
+}
+
+// receiveFileEvents returns the file names of the next n events from out keyed
+// by message.
+func receiveFileEvents(t *testing.T, out <-chan *event.Event, n int) map[string]string {
+	received := make(map[string]string, n)
+	for len(received) < n {
+		e := receiveEvent(t, out)
+		received[e.GetMessage()] = filepath.Base(event.ValueString(e.Get("path")))
+	}
+	return received
+}
+
+func TestFileInputGlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(interval time.Duration) { discoverInterval = interval }(discoverInterval)
+	discoverInterval = 50 * time.Millisecond
+
+	appendTestFile(t, filepath.Join(dir, "a.log"), "a\n")
+	appendTestFile(t, filepath.Join(dir, "b.txt"), "b\n")
+	appendTestFile(t, filepath.Join(dir, "skip.log"), "skip\n")
+	appendTestFile(t, filepath.Join(dir, "other.csv"), "other\n")
+	os.Mkdir(filepath.Join(dir, "dir.log"), 0755)
+
+	input, err := newFileInput(map[string]interface{}{
+		"path":           []string{filepath.Join(dir, "*.log"), filepath.Join(dir, "*.txt")},
+		"exclude":        "skip*",
+		"start_position": "end",
+		"sincedb_path":   filepath.Join(dir, "sincedb"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan *event.Event, 10)
+	input.Start(out)
+	defer input.Close()
+	time.Sleep(100 * time.Millisecond) // Let tailing start
+
+	// Files found at start are read from the end, files created later
+	// are read from the beginning
+	appendTestFile(t, filepath.Join(dir, "a.log"), "a2\n")
+	appendTestFile(t, filepath.Join(dir, "b.txt"), "b2\n")
+	appendTestFile(t, filepath.Join(dir, "skip.log"), "skip2\n")
+	appendTestFile(t, filepath.Join(dir, "c.log"), "c\n")
+	appendTestFile(t, filepath.Join(dir, "skip2.log"), "skip3\n")
+
+	expected := map[string]string{"a2": "a.log", "b2": "b.txt", "c": "c.log"}
+	if received := receiveFileEvents(t, out, 3); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected %v, got %v", expected, received)
+	}
+
+	select {
+	case e := <-out:
+		t.Errorf("Unexpected event %q from %v", e.GetMessage(), e.Get("path"))
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestFileInputMultilineFlush(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	multilineInput := func(path, timeout string) Input {
+		multiline := map[string]interface{}{"pattern": `^\s`, "what": "previous"}
+		if timeout != "" {
+			multiline["timeout"] = timeout
+		}
+		input, err := newFileInput(map[string]interface{}{
+			"path":           path,
+			"start_position": "beginning",
+			"sincedb_path":   filepath.Join(dir, "sincedb"),
+			"multiline":      utils.NewMap(multiline),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return input
+	}
+
+	// Held lines are flushed after the timeout
+	path := filepath.Join(dir, "a.log")
+	appendTestFile(t, path, "a\n  b\n")
+	input := multilineInput(path, "100ms")
+	out := make(chan *event.Event, 10)
+	input.Start(out)
+	if e := receiveEvent(t, out); e.GetMessage() != "a\n  b" || e.Get("offset") != int64(0) {
+		t.Errorf("Expected message %q at offset 0, got %q at %v", "a\n  b", e.GetMessage(), e.Get("offset"))
+	}
+	input.Close()
+
+	// Without a timeout, the last event is flushed on close
+	path = filepath.Join(dir, "b.log")
+	appendTestFile(t, path, "c\n  d\n")
+	input = multilineInput(path, "")
+	out = make(chan *event.Event, 10)
+	input.Start(out)
+	time.Sleep(200 * time.Millisecond) // Let the lines be read
+	select {
+	case e := <-out:
+		t.Fatalf("Unexpected event %q before close", e.GetMessage())
+	default:
+	}
+	input.Close()
+
+	select {
+	case e := <-out:
+		if e.GetMessage() != "c\n  d" || e.Get("offset") != int64(0) {
+			t.Errorf("Expected message %q at offset 0, got %q at %v", "c\n  d", e.GetMessage(), e.Get("offset"))
+		}
+	default:
+		t.Fatal("Last event wasn't flushed on close")
+	}
+
+	// The flushed lines aren't read again
+	db, err := openSincedb(filepath.Join(dir, "sincedb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	id, _ := getFileID(info)
+	if offset, _ := db.get(id); offset != 6 {
+		t.Errorf("Expected saved offset 6 after close, got %d", offset)
+	}
+}
@@ -0,0 +1,138 @@
+package inputs
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("generator", newGeneratorInput)
+}
+
+type generatorConfig struct {
+	lines []string
+	count uint64
+	rate  float64
+}
+
+// A GeneratorInput creates events with configured messages, for testing and
+// benchmarking pipelines. The lines option is a message or array of messages
+// used in turn. Field references such as %{sequence} in a message are replaced
+// with the event's fields. Events are created at rate events per second, or as fast as
+// possible if rate is 0. If count is greater than 0, the input finishes after
+// creating count events. Events have the field sequence set to their position,
+// starting at 0, and host set to the local hostname.
+type GeneratorInput struct {
+	config *generatorConfig
+	host   string
+	t      tomb.Tomb
+	out    chan<- *event.Event
+	done   chan struct{}
+}
+
+func newGeneratorInput(options map[string]interface{}) (Input, error) {
+	i := &GeneratorInput{
+		config: &generatorConfig{
+			lines: []string{"Hello world!"},
+		},
+		host: hostname(),
+		done: make(chan struct{}),
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *GeneratorInput) setConfig(options map[string]interface{}) error {
+	if s, exists := options["lines"]; exists {
+		lines, ok := stringSlice(s)
+		if !ok || len(lines) == 0 {
+			return errors.New("lines must be a string or non-empty array of strings")
+		}
+		i.config.lines = lines
+	}
+
+	if s, exists := options["count"]; exists {
+		count, ok := s.(int)
+		if !ok || count < 0 {
+			return errors.New("count must be a non-negative integer")
+		}
+		i.config.count = uint64(count)
+	}
+
+	if s, exists := options["rate"]; exists {
+		var rate float64
+		switch s := s.(type) {
+		case int:
+			rate = float64(s)
+		case float64:
+			rate = s
+		default:
+			return errors.New("rate must be a number")
+		}
+		if rate < 0 {
+			return errors.New("rate must not be negative")
+		}
+		i.config.rate = rate
+	}
+
+	return nil
+}
+
+// Start creating events.
+func (i *GeneratorInput) Start(out chan<- *event.Event) error {
+	i.out = out
+	i.t.Go(i.run)
+	return nil
+}
+
+// Close stops creating events.
+func (i *GeneratorInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+// Done is closed after count events have been sent. If count is 0,
+// nil is returned.
+func (i *GeneratorInput) Done() <-chan struct{} {
+	if i.config.count == 0 {
+		return nil
+	}
+	return i.done
+}
+
+func (i *GeneratorInput) run() error {
+	start := time.Now()
+
+	for seq := uint64(0); i.config.count == 0 || seq < i.config.count; seq++ {
+		if i.config.rate > 0 {
+			// Pace from the start time so slow sends don't lower the rate
+			next := start.Add(time.Duration(float64(seq) / i.config.rate * float64(time.Second)))
+			if wait := next.Sub(time.Now()); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-i.t.Dying():
+					return nil
+				}
+			}
+		}
+
+		line := i.config.lines[seq%uint64(len(i.config.lines))]
+		e := event.New("")
+		e.Set("sequence", int(seq))
+		e.Set("host", i.host)
+		e.SetMessage(e.Sprintf(line))
+
+		select {
+		case i.out <- e:
+		case <-i.t.Dying():
+			return nil
+		}
+	}
+
+	utils.Log.Infof("Generator input: created %d events", i.config.count)
+	close(i.done)
+	return nil
+}
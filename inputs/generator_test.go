@@ -0,0 +1,135 @@
+package inputs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/filters"
+)
+
+func TestGeneratorInput(t *testing.T) {
+	input, err := newGeneratorInput(map[string]interface{}{
+		"lines": []string{"first %{sequence}", "second"},
+		"count": 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := make(chan *event.Event, 3)
+	input.Start(out)
+	defer input.Close()
+
+	select {
+	case <-input.(Finisher).Done():
+	case <-time.After(time.Second):
+		t.Fatal("Generator didn't finish")
+	}
+
+	expected := []string{"first 0", "second", "first 2"}
+	for seq, message := range expected {
+		e := <-out
+		if e.GetMessage() != message {
+			t.Errorf("Expected message %q, got %q", message, e.GetMessage())
+		}
+		if e.Get("sequence") != seq {
+			t.Errorf("Expected sequence %d, got %v", seq, e.Get("sequence"))
+		}
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected 3 events, got %d more", len(out))
+	}
+}
+
+func TestGeneratorInputConditional(t *testing.T) {
+	pf, err := parser.ParseString(`filter {
+	if [sequence] > 3 {
+		mutate {
+			action => "add_field"
+			fields => { late => "yes" }
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := filters.GeneratePipeline(pf.Filters)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := newGeneratorInput(map[string]interface{}{"count": 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(chan *event.Event, 6)
+	input.Start(out)
+	defer input.Close()
+
+	batch := make([]*event.Event, 6)
+	for i := range batch {
+		batch[i] = <-out
+	}
+	for i, e := range pipeline.Run(batch) {
+		if e.HasField("late") != (i > 3) {
+			t.Errorf("Event %d: expected late to be set only if sequence > 3", i)
+		}
+	}
+}
+
+func TestGeneratorInputOptions(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{"lines": []interface{}{}},
+		{"count": -1},
+		{"count": "10"},
+		{"rate": -5},
+		{"rate": "fast"},
+	}
+	for _, options := range invalid {
+		if _, err := newGeneratorInput(options); err == nil {
+			t.Errorf("Expected error for options %v", options)
+		}
+	}
+
+	input, err := newGeneratorInput(map[string]interface{}{"rate": 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.(Finisher).Done() != nil {
+		t.Error("Expected generator without count to never finish")
+	}
+}
+
+func BenchmarkGeneratorFilters(b *testing.B) {
+	mutate, err := filters.New("mutate", map[string]interface{}{
+		"fields": "sequence",
+		"action": "remove_field",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	end, _ := filters.New("end", nil)
+	mutate.SetNext(end)
+
+	input, _ := newGeneratorInput(map[string]interface{}{
+		"lines": "benchmark event %{sequence}",
+		"count": b.N,
+	})
+	controller := filters.NewFilterController([]filters.Filter{mutate}, 125, 10*time.Millisecond, false)
+
+	in := make(chan *event.Event, 1000)
+	out := make(chan *event.Event, 1000)
+
+	b.ResetTimer()
+	controller.Start(in, out)
+	input.Start(in)
+	for n := 0; n < b.N; n++ {
+		<-out
+	}
+	b.StopTimer()
+
+	input.Close()
+	controller.Close()
+}
@@ -0,0 +1,342 @@
+package inputs
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("http", newHTTPInput)
+}
+
+// maxHTTPBody is the largest request body accepted by the http input.
+const maxHTTPBody = 10 << 20
+
+// httpShutdownTimeout is how long requests being handled have to finish
+// when the http input is closed before their connections are closed.
+const httpShutdownTimeout = 5 * time.Second
+
+type httpConfig struct {
+	address     string
+	codec       *codecs.Def
+	delimiter   []byte
+	timeout     time.Duration
+	user        string
+	password    string
+	token       string
+	tokenHeader string
+}
+
+// An HTTPInput runs an HTTP server accepting events in POST request bodies. A body
+// may be a JSON object, a JSON array of objects, newline delimited JSON objects, or
+// lines separated by a delimiter, a newline by default. Each object or line is decoded
+// with the configured codec, or used as the message if no codec is set. Data held by
+// codecs such as multiline is flushed at the end of each body. Events have the field
+// host set to the client address unless the codec set it, and headers set to the
+// request headers.
+//
+// Requests may be required to use basic authentication with the user and password
+// options, or include a token in the header named by token_header. If events can't
+// be sent to the pipeline within timeout, the server responds with 503 Service
+// Unavailable. Events sent before the timeout aren't removed from the pipeline.
+type HTTPInput struct {
+	config   *httpConfig
+	t        tomb.Tomb
+	out      chan<- *event.Event
+	listener net.Listener
+	server   *http.Server
+}
+
+func newHTTPInput(options map[string]interface{}) (Input, error) {
+	i := &HTTPInput{
+		config: &httpConfig{
+			timeout:     5 * time.Second,
+			tokenHeader: "X-Auth-Token",
+		},
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *HTTPInput) setConfig(options map[string]interface{}) error {
+	if s, exists := options["address"]; exists {
+		address, ok := s.(string)
+		if !ok {
+			return errors.New("address must be a string")
+		}
+		i.config.address = address
+	} else {
+		return errors.New("address option required")
+	}
+
+	codec, err := codecOption(options)
+	if err != nil {
+		return err
+	}
+	i.config.codec = codec
+
+	i.config.delimiter, err = delimiterOption(options, codec)
+	if err != nil {
+		return err
+	}
+
+	if s, exists := options["timeout"]; exists {
+		str, ok := s.(string)
+		if !ok {
+			return errors.New("timeout must be a duration string such as \"5s\"")
+		}
+		timeout, err := time.ParseDuration(str)
+		if err != nil || timeout <= 0 {
+			return errors.New("timeout must be a duration string such as \"5s\"")
+		}
+		i.config.timeout = timeout
+	}
+
+	for _, opt := range []struct {
+		name string
+		val  *string
+	}{
+		{"user", &i.config.user},
+		{"password", &i.config.password},
+		{"token", &i.config.token},
+		{"token_header", &i.config.tokenHeader},
+	} {
+		if s, exists := options[opt.name]; exists {
+			str, ok := s.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a string", opt.name)
+			}
+			*opt.val = str
+		}
+	}
+
+	if (i.config.user == "") != (i.config.password == "") {
+		return errors.New("user and password must be set together")
+	}
+	if i.config.tokenHeader == "" {
+		return errors.New("token_header must not be empty")
+	}
+
+	return nil
+}
+
+// Start the HTTP server.
+func (i *HTTPInput) Start(out chan<- *event.Event) error {
+	i.out = out
+
+	l, err := net.Listen("tcp", i.config.address)
+	if err != nil {
+		return err
+	}
+	i.listener = l
+	i.server = &http.Server{Handler: i}
+
+	i.t.Go(func() error {
+		utils.Log.Infof("HTTP input: listening on %s", l.Addr())
+		err := i.server.Serve(l)
+		select {
+		case <-i.t.Dying():
+			return nil
+		default:
+			return err
+		}
+	})
+	i.t.Go(func() error {
+		<-i.t.Dying()
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := i.server.Shutdown(ctx); err != nil {
+			i.server.Close()
+		}
+		return nil
+	})
+	return nil
+}
+
+// Close stops the HTTP server and closes idle connections. Requests being
+// handled are answered with 503 Service Unavailable.
+func (i *HTTPInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+func (i *HTTPInput) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !i.authorized(r) {
+		if i.config.user != "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="spartan"`)
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHTTPBody+1))
+	if err != nil {
+		http.Error(w, "Error reading body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPBody {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	events, err := i.decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	timeout := time.NewTimer(i.config.timeout)
+	defer timeout.Stop()
+
+	for _, e := range events {
+		if !e.HasField("host") {
+			e.Set("host", host)
+		}
+		// Events may be changed by different filter workers, each
+		// needs its own map
+		e.Set("headers", i.headers(r))
+
+		select {
+		case i.out <- e:
+		case <-timeout.C:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Pipeline is busy", http.StatusServiceUnavailable)
+			return
+		case <-i.t.Dying():
+			http.Error(w, "Shutting down", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, "ok\n")
+}
+
+// authorized checks the request's basic authentication or token if required.
+func (i *HTTPInput) authorized(r *http.Request) bool {
+	if i.config.user != "" {
+		user, password, ok := r.BasicAuth()
+		if !ok || !secureCompare(user, i.config.user) || !secureCompare(password, i.config.password) {
+			return false
+		}
+	}
+	if i.config.token != "" {
+		if !secureCompare(r.Header.Get(i.config.tokenHeader), i.config.token) {
+			return false
+		}
+	}
+	return true
+}
+
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// headers returns the request headers as a map. Headers used for
+// authentication are excluded.
+func (i *HTTPInput) headers(r *http.Request) map[string]string {
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		if name == "Authorization" || (i.config.token != "" && name == http.CanonicalHeaderKey(i.config.tokenHeader)) {
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// decode creates Events from each frame in body. Data held by the codec is
+// flushed after the last frame.
+func (i *HTTPInput) decode(body []byte) ([]*event.Event, error) {
+	codec := newCodec(i.config.codec)
+	frames, err := splitHTTPBody(body, i.config.delimiter, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*event.Event, 0, len(frames))
+	for _, frame := range frames {
+		e, err := decodeFrame(codec, frame)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			events = append(events, e)
+		}
+	}
+
+	if flusher, ok := codec.(codecs.Flusher); ok {
+		if e := flusher.Flush(); e != nil {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// splitHTTPBody splits body into frames. The encoded Events of a Framed codec are
+// split from each other. Otherwise a JSON array is split into its elements, JSON
+// objects are split from each other, and anything else is split on delim.
+func splitHTTPBody(body, delim []byte, codec codecs.Codec) ([][]byte, error) {
+	if _, ok := codec.(codecs.Framed); ok {
+		return splitFrames(body, delim, codec)
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(trimmed, &elements); err != nil {
+			return nil, fmt.Errorf("Invalid JSON array: %v", err)
+		}
+		frames := make([][]byte, len(elements))
+		for i, element := range elements {
+			frames[i] = element
+		}
+		return frames, nil
+
+	case '{':
+		var frames [][]byte
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			var object json.RawMessage
+			if err := dec.Decode(&object); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("Invalid JSON: %v", err)
+			}
+			frames = append(frames, object)
+		}
+		return frames, nil
+	}
+
+	return splitFrames(body, delim, nil)
+}
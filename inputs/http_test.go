@@ -0,0 +1,247 @@
+package inputs
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+func TestSplitHTTPBody(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected []string
+	}{
+		{`{"a": 1}`, []string{`{"a": 1}`}},
+		{"{\"a\": 1}\n{\"b\": 2}\n", []string{`{"a": 1}`, `{"b": 2}`}},
+		{"{\n  \"a\": 1\n}", []string{"{\n  \"a\": 1\n}"}},
+		{` [{"a": 1}, {"b": 2}]`, []string{`{"a": 1}`, `{"b": 2}`}},
+		{"line one\nline two\n", []string{"line one", "line two"}},
+		{"  \n", nil},
+	}
+
+	for _, test := range tests {
+		frames, err := splitHTTPBody([]byte(test.body), []byte{'\n'}, nil)
+		if err != nil {
+			t.Errorf("%q: %v", test.body, err)
+			continue
+		}
+
+		var got []string
+		for _, frame := range frames {
+			got = append(got, string(frame))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.body, test.expected, got)
+		}
+	}
+
+	for _, body := range []string{`[{"a": 1}`, `{"a": 1} {`} {
+		if _, err := splitHTTPBody([]byte(body), []byte{'\n'}, nil); err == nil {
+			t.Errorf("%q: expected an error", body)
+		}
+	}
+}
+
+// httpTest creates an HTTPInput with options sending events to out.
+func httpTest(t *testing.T, options map[string]interface{}, out chan<- *event.Event) *HTTPInput {
+	options["address"] = "127.0.0.1:0"
+	i, err := newHTTPInput(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := i.(*HTTPInput)
+	input.out = out
+	return input
+}
+
+// httpRequest sends a request to input and returns the response status.
+func httpRequest(input *HTTPInput, method, body string, header http.Header) int {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	input.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestHTTPInputAuth(t *testing.T) {
+	out := make(chan *event.Event, 10)
+
+	input := httpTest(t, map[string]interface{}{
+		"user":     "admin",
+		"password": "secret",
+	}, out)
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.SetBasicAuth("admin", "secret")
+	tests := []struct {
+		header   http.Header
+		expected int
+	}{
+		{nil, http.StatusUnauthorized},
+		{http.Header{"Authorization": {"Basic YWRtaW46d3Jvbmc="}}, http.StatusUnauthorized},
+		{r.Header, http.StatusOK},
+	}
+	for i, test := range tests {
+		if code := httpRequest(input, "POST", "line", test.header); code != test.expected {
+			t.Errorf("Basic auth test %d: expected %d, got %d", i+1, test.expected, code)
+		}
+	}
+
+	input = httpTest(t, map[string]interface{}{"token": "abc"}, out)
+	tests = []struct {
+		header   http.Header
+		expected int
+	}{
+		{nil, http.StatusUnauthorized},
+		{http.Header{"X-Auth-Token": {"abd"}}, http.StatusUnauthorized},
+		{http.Header{"X-Auth-Token": {"abc"}}, http.StatusOK},
+	}
+	for i, test := range tests {
+		if code := httpRequest(input, "POST", "line", test.header); code != test.expected {
+			t.Errorf("Token test %d: expected %d, got %d", i+1, test.expected, code)
+		}
+	}
+}
+
+func TestHTTPInputErrors(t *testing.T) {
+	input := httpTest(t, map[string]interface{}{"timeout": "10ms"}, make(chan *event.Event))
+
+	if code := httpRequest(input, "GET", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected %d, got %d", http.StatusMethodNotAllowed, code)
+	}
+
+	body := strings.Repeat("a", maxHTTPBody+1)
+	if code := httpRequest(input, "POST", body, nil); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Large body: expected %d, got %d", http.StatusRequestEntityTooLarge, code)
+	}
+
+	// Nothing reads from the pipeline
+	if code := httpRequest(input, "POST", "line", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("Full pipeline: expected %d, got %d", http.StatusServiceUnavailable, code)
+	}
+}
+
+func TestHTTPInputFields(t *testing.T) {
+	out := make(chan *event.Event, 10)
+	input := httpTest(t, map[string]interface{}{"token": "abc"}, out)
+
+	header := http.Header{
+		"X-Auth-Token": {"abc"},
+		"X-Source":     {"app1", "app2"},
+	}
+	if code := httpRequest(input, "POST", "one\ntwo\n", header); code != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, code)
+	}
+	close(out)
+
+	var events []*event.Event
+	for e := range out {
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	expected := map[string]string{"X-Source": "app1, app2"}
+	for _, e := range events {
+		if e.Get("host") != "192.0.2.1" {
+			t.Errorf("Expected host 192.0.2.1, got %v", e.Get("host"))
+		}
+		if !reflect.DeepEqual(e.Get("headers"), expected) {
+			t.Errorf("Expected headers %v, got %v", expected, e.Get("headers"))
+		}
+	}
+
+	// Each event has its own headers
+	events[0].Set("[headers][X-Source]", "changed")
+	if events[1].Get("[headers][X-Source]") != "app1, app2" {
+		t.Error("Events share the headers map")
+	}
+}
+
+func TestHTTPInputCodecs(t *testing.T) {
+	msgpack, _ := codecs.New("msgpack", nil)
+	binaryBody := string(msgpack.Encode(event.New("a\nb"))) + string(msgpack.Encode(event.New("c ")))
+
+	tests := []struct {
+		codec    interface{}
+		body     string
+		expected []string
+	}{
+		// The decoded host is kept
+		{"json", `{"message": "a", "host": "web1"}`, []string{"a web1"}},
+		// The last event held by the codec is flushed
+		{&parser.ModuleDef{Module: "multiline", Options: utils.NewMap(map[string]interface{}{
+			"pattern": `^\s`,
+			"what":    "previous",
+		})}, "a\n  b\nc\n  d\n", []string{"a\n  b 192.0.2.1", "c\n  d 192.0.2.1"}},
+		// The body is split on the codec's delimiter
+		{&parser.ModuleDef{Module: "line", Options: utils.NewMap(map[string]interface{}{
+			"delimiter": ";",
+		})}, "a;b;c", []string{"a 192.0.2.1", "b 192.0.2.1", "c 192.0.2.1"}},
+		// Binary events are split from each other, not on a delimiter or whitespace
+		{"msgpack", binaryBody, []string{"a\nb 192.0.2.1", "c  192.0.2.1"}},
+	}
+
+	for _, test := range tests {
+		out := make(chan *event.Event, 10)
+		input := httpTest(t, map[string]interface{}{"codec": test.codec}, out)
+		if code := httpRequest(input, "POST", test.body, nil); code != http.StatusOK {
+			t.Errorf("%q: expected %d, got %d", test.body, http.StatusOK, code)
+			continue
+		}
+		close(out)
+
+		var got []string
+		for e := range out {
+			got = append(got, e.GetMessage()+" "+event.ValueString(e.Get("host")))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.body, test.expected, got)
+		}
+	}
+}
+
+func TestHTTPInputClose(t *testing.T) {
+	i, err := newHTTPInput(map[string]interface{}{"address": "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := i.(*HTTPInput)
+	if err := input.Start(make(chan *event.Event, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// An idle keep-alive connection is closed
+	conn, err := net.Dial("tcp", input.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("POST / HTTP/1.1\r\nHost: test\r\nContent-Length: 4\r\n\r\nline"))
+	buf := make([]byte, 1024)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := input.Close(); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(buf); err == nil {
+		t.Error("Expected idle connection to be closed")
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Error("Idle connection wasn't closed")
+	}
+}
@@ -2,6 +2,7 @@ package inputs
 
 import (
 	"errors"
+	"os"
 
 	"github.com/lfkeitel/spartan/event"
 )
@@ -16,6 +17,35 @@ type Input interface {
 	Close() error
 }
 
+// A Finisher is an Input that stops generating events on its own, for example
+// at the end of a stream.
+type Finisher interface {
+	// Done returns a channel that's closed after the Input has sent its last Event.
+	// If the Input will never finish, nil is returned.
+	Done() <-chan struct{}
+}
+
+// Finished returns a channel that's closed once all inputs have finished.
+// If any Input isn't a Finisher, the channel is never closed.
+func Finished(inputs []Input) <-chan struct{} {
+	finished := make(chan struct{})
+	go func() {
+		for _, input := range inputs {
+			f, ok := input.(Finisher)
+			if !ok {
+				return
+			}
+			if done := f.Done(); done != nil {
+				<-done
+			} else {
+				return
+			}
+		}
+		close(finished)
+	}()
+	return finished
+}
+
 type initFunc func(map[string]interface{}) (Input, error)
 
 var (
@@ -41,5 +71,37 @@ func New(name string, options map[string]interface{}) (Input, error) {
 	if !exists {
 		return nil, ErrInputNotRegistered
 	}
-	return init(options)
+
+	common, options, err := splitCommonOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := init(options)
+	if err != nil || common == nil {
+		return input, err
+	}
+	return &commonInput{input: input, config: common}, nil
+}
+
+// stringSlice returns val as a slice if it's a string or array of strings.
+func stringSlice(val interface{}) ([]string, bool) {
+	switch val := val.(type) {
+	case string:
+		return []string{val}, true
+	case []string:
+		return val, true
+	case []interface{}: // Empty array
+		return []string{}, len(val) == 0
+	}
+	return nil, false
+}
+
+// hostname returns the name of the local host or an empty string if it's unknown.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
 }
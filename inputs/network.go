@@ -0,0 +1,314 @@
+package inputs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+// maxFrameSize is the largest frame a frameReader will buffer.
+const maxFrameSize = 1 << 20
+
+var (
+	errFrameTooLong    = errors.New("frame exceeds maximum size")
+	errIncompleteFrame = errors.New("data ends with an incomplete event")
+)
+
+// A closerSet tracks the listeners and connections of a network Input so they
+// can all be closed when the Input is closed.
+type closerSet struct {
+	mu      sync.Mutex
+	closers map[io.Closer]bool
+	closed  bool
+}
+
+// add records c to be closed by closeAll. If closeAll was already called,
+// c is closed immediately and false is returned.
+func (s *closerSet) add(c io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		c.Close()
+		return false
+	}
+	if s.closers == nil {
+		s.closers = make(map[io.Closer]bool)
+	}
+	s.closers[c] = true
+	return true
+}
+
+// remove stops tracking c.
+func (s *closerSet) remove(c io.Closer) {
+	s.mu.Lock()
+	delete(s.closers, c)
+	s.mu.Unlock()
+}
+
+// closeAll closes everything added to the set.
+func (s *closerSet) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for c := range s.closers {
+		c.Close()
+	}
+}
+
+// acceptConns accepts connections on l until t is dying. Each connection is
+// tracked in closers and passed to handle in a new go routine of t, then closed
+// when handle returns. Connections that allow returns false for are closed
+// without calling handle. allow may be nil. name is used in logged errors.
+func acceptConns(t *tomb.Tomb, l net.Listener, closers *closerSet, name string, allow func(net.Conn) bool, handle func(net.Conn)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-t.Dying():
+				return
+			default:
+			}
+			utils.Log.Errorf("%s: %v", name, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		if !closers.add(conn) {
+			continue
+		}
+		if allow != nil && !allow(conn) {
+			closers.remove(conn)
+			conn.Close()
+			continue
+		}
+
+		t.Go(func() error {
+			handle(conn)
+			closers.remove(conn)
+			conn.Close()
+			return nil
+		})
+	}
+}
+
+// A framer finds the length of the first frame in data. Zero is returned if
+// data doesn't hold a complete frame yet.
+type framer interface {
+	FrameLength(data []byte) (int, error)
+}
+
+// A frameReader splits a stream into frames separated by a delimiter, or into
+// frames found by a framer such as a Framed codec.
+type frameReader struct {
+	r      io.Reader
+	delim  []byte
+	framed framer
+	buf    []byte
+	chunk  []byte
+}
+
+// newFrameReader creates a frameReader splitting r on delim, or with codec
+// if it's Framed. codec may be nil.
+func newFrameReader(r io.Reader, delim []byte, codec codecs.Codec) *frameReader {
+	if framed, ok := codec.(codecs.Framed); ok {
+		return newFramerReader(r, framed)
+	}
+	return &frameReader{
+		r:     r,
+		delim: delim,
+		chunk: make([]byte, 4096),
+	}
+}
+
+// newFramerReader creates a frameReader splitting r with framed.
+func newFramerReader(r io.Reader, framed framer) *frameReader {
+	return &frameReader{
+		r:      r,
+		framed: framed,
+		chunk:  make([]byte, 4096),
+	}
+}
+
+// next returns the next frame without its delimiter. Data read before an
+// error is kept and returned by a later call to next or rest.
+func (f *frameReader) next() ([]byte, error) {
+	for {
+		if frame, ok, err := f.split(); ok || err != nil {
+			return frame, err
+		}
+		if len(f.buf) > maxFrameSize {
+			return nil, errFrameTooLong
+		}
+
+		n, err := f.r.Read(f.chunk)
+		f.buf = append(f.buf, f.chunk[:n]...)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// split removes the first complete frame from the buffer. ok is false if
+// there isn't one.
+func (f *frameReader) split() (frame []byte, ok bool, err error) {
+	n, skip := -1, 0
+	if f.framed != nil {
+		length, err := f.framed.FrameLength(f.buf)
+		if err != nil {
+			return nil, false, err
+		}
+		if length > 0 {
+			n = length
+		}
+	} else if i := bytes.Index(f.buf, f.delim); i >= 0 {
+		n, skip = i, len(f.delim)
+	}
+	if n < 0 {
+		return nil, false, nil
+	}
+
+	frame = append([]byte(nil), f.buf[:n]...)
+	f.buf = f.buf[n+skip:]
+	return frame, true, nil
+}
+
+// rest returns the data read after the last delimiter.
+func (f *frameReader) rest() []byte {
+	rest := f.buf
+	f.buf = nil
+	return rest
+}
+
+// splitFrames splits data into frames separated by delim, or into the encoded
+// Events of codec if it's Framed. Empty frames are removed. codec may be nil.
+func splitFrames(data, delim []byte, codec codecs.Codec) ([][]byte, error) {
+	if framed, ok := codec.(codecs.Framed); ok {
+		var frames [][]byte
+		for len(data) > 0 {
+			n, err := framed.FrameLength(data)
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return nil, errIncompleteFrame
+			}
+			frames = append(frames, data[:n])
+			data = data[n:]
+		}
+		return frames, nil
+	}
+
+	frames := bytes.Split(data, delim)
+	n := 0
+	for _, frame := range frames {
+		if len(frame) > 0 {
+			frames[n] = frame
+			n++
+		}
+	}
+	return frames[:n], nil
+}
+
+// decodeFrame creates an Event from frame using codec. If codec is nil, the frame
+// is used as the Event message. The returned Event may be nil if the codec needs
+// more frames to create an Event.
+func decodeFrame(codec codecs.Codec, frame []byte) (*event.Event, error) {
+	if codec == nil {
+		return event.New(string(frame)), nil
+	}
+	return codec.Decode(frame)
+}
+
+// setSourceFields sets the host and port fields of e to addr. Fields
+// already set, such as by a codec, are kept.
+func setSourceFields(e *event.Event, addr net.Addr) {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	if !e.HasField("host") {
+		e.Set("host", host)
+	}
+	if e.HasField("port") {
+		return
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		e.Set("port", p)
+	}
+}
+
+// networkConfig contains the options shared by the tcp and udp inputs.
+type networkConfig struct {
+	address   string
+	codec     *codecs.Def
+	delimiter []byte
+}
+
+func (c *networkConfig) setConfig(options map[string]interface{}) error {
+	if s, exists := options["address"]; exists {
+		address, ok := s.(string)
+		if !ok {
+			return errors.New("address must be a string")
+		}
+		c.address = address
+	} else {
+		return errors.New("address option required")
+	}
+
+	codec, err := codecOption(options)
+	if err != nil {
+		return err
+	}
+	c.codec = codec
+
+	c.delimiter, err = delimiterOption(options, codec)
+	return err
+}
+
+// delimiterOption returns the delimiter option. If it isn't set, the delimiter of
+// a Delimited codec such as line is used, otherwise a newline.
+func delimiterOption(options map[string]interface{}, codec *codecs.Def) ([]byte, error) {
+	if s, exists := options["delimiter"]; exists {
+		return utils.ParseDelimiter(s)
+	}
+	if c, ok := newCodec(codec).(codecs.Delimited); ok {
+		return c.Delimiter(), nil
+	}
+	return []byte{'\n'}, nil
+}
+
+// codecOption returns the codec definition given in the codec option.
+// nil is returned if the option isn't set.
+func codecOption(options map[string]interface{}) (*codecs.Def, error) {
+	s, exists := options["codec"]
+	if !exists {
+		return nil, nil
+	}
+	name, codecOptions, ok := parser.ModuleOption(s)
+	if !ok {
+		return nil, errors.New("codec must be a codec name or definition")
+	}
+	return codecs.ParseDef(name, codecOptions)
+}
+
+// newCodec creates an instance of the codec def, or returns nil if def is nil.
+// The definition must come from codecOption.
+func newCodec(def *codecs.Def) codecs.Codec {
+	if def == nil {
+		return nil
+	}
+	codec, _ := def.New() // Checked by codecOption
+	return codec
+}
@@ -0,0 +1,187 @@
+package inputs
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestFrameReader(t *testing.T) {
+	// Read one byte at a time so delimiters are split between reads
+	r := newFrameReader(iotest.OneByteReader(strings.NewReader("one\r\ntwo\r\n\r\nthree")), []byte("\r\n"), nil)
+
+	var frames []string
+	for {
+		frame, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, string(frame))
+	}
+
+	expected := []string{"one", "two", ""}
+	if !reflect.DeepEqual(frames, expected) {
+		t.Errorf("Expected %q, got %q", expected, frames)
+	}
+	if rest := string(r.rest()); rest != "three" {
+		t.Errorf("Expected rest to be three, got %q", rest)
+	}
+}
+
+func TestSplitFrames(t *testing.T) {
+	frames, err := splitFrames([]byte("a\n\nb\nc\n"), []byte("\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, frame := range frames {
+		got = append(got, string(frame))
+	}
+
+	expected := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestFrameReaderFramed(t *testing.T) {
+	codec, _ := codecs.New("msgpack", nil)
+	var stream []byte
+	for _, msg := range []string{"one\ntwo", "three\n"} {
+		stream = append(stream, codec.Encode(event.New(msg))...)
+	}
+
+	r := newFrameReader(iotest.OneByteReader(bytes.NewReader(stream)), []byte("\n"), codec)
+	var messages []string
+	for {
+		frame, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := codec.Decode(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, e.GetMessage())
+	}
+
+	expected := []string{"one\ntwo", "three\n"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expected %q, got %q", expected, messages)
+	}
+	if rest := r.rest(); len(rest) != 0 {
+		t.Errorf("Expected no data left, got % x", rest)
+	}
+}
+
+func TestSplitFramesFramed(t *testing.T) {
+	codec, _ := codecs.New("cbor", nil)
+	data := append(codec.Encode(event.New("a\nb")), codec.Encode(event.New("c"))...)
+
+	frames, err := splitFrames(data, []byte("\n"), codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(frames))
+	}
+
+	if _, err := splitFrames(data[:len(data)-1], []byte("\n"), codec); err != errIncompleteFrame {
+		t.Errorf("Expected incomplete frame error, got %v", err)
+	}
+}
+
+func TestSetSourceFields(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}
+
+	e := event.New("")
+	setSourceFields(e, addr)
+	if e.Get("host") != "10.0.0.1" || e.Get("port") != 5000 {
+		t.Errorf("Expected host 10.0.0.1 and port 5000, got %v and %v", e.Get("host"), e.Get("port"))
+	}
+
+	e = event.New("")
+	e.Set("host", "web1")
+	e.Set("port", 80)
+	setSourceFields(e, addr)
+	if e.Get("host") != "web1" || e.Get("port") != 80 {
+		t.Errorf("Expected decoded host web1 and port 80, got %v and %v", e.Get("host"), e.Get("port"))
+	}
+}
+
+func TestUDPInputFlusherCodec(t *testing.T) {
+	pf, err := parser.ParseString(`input {
+	udp {
+		address => "127.0.0.1:0"
+		codec => multiline {
+			pattern => "^\\s"
+			what => "previous"
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newUDPInput(pf.Inputs[0].Options.Map()); err == nil {
+		t.Error("Expected error for multiline codec")
+	}
+
+	if _, err := newUDPInput(map[string]interface{}{
+		"address": "127.0.0.1:0",
+		"codec":   "json",
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDelimiterOption(t *testing.T) {
+	line, err := codecs.ParseDef("line", map[string]interface{}{"delimiter": `\x00`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	latin1, err := codecs.ParseDef("line", map[string]interface{}{"delimiter": ";", "charset": "latin1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		options  map[string]interface{}
+		codec    *codecs.Def
+		expected string
+	}{
+		{map[string]interface{}{}, nil, "\n"},
+		{map[string]interface{}{"delimiter": `\r\n`}, nil, "\r\n"},
+		{map[string]interface{}{}, line, "\x00"},
+		{map[string]interface{}{"delimiter": ";"}, line, ";"},
+		{map[string]interface{}{}, latin1, ";"},
+	}
+	for i, test := range tests {
+		delim, err := delimiterOption(test.options, test.codec)
+		if err != nil {
+			t.Errorf("Test %d: %v", i+1, err)
+		} else if string(delim) != test.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, test.expected, delim)
+		}
+	}
+
+	for _, delim := range []interface{}{"", 5, `\q`} {
+		if _, err := delimiterOption(map[string]interface{}{"delimiter": delim}, nil); err == nil {
+			t.Errorf("Expected error for delimiter %#v", delim)
+		}
+	}
+}
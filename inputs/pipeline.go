@@ -0,0 +1,21 @@
+package inputs
+
+import (
+	"fmt"
+
+	"github.com/lfkeitel/spartan/config/parser"
+)
+
+// CreateFromDefs creates the Input instances described by defs. Inputs are
+// returned in the same order as their definitions.
+func CreateFromDefs(defs []*parser.InputDef) ([]Input, error) {
+	inputs := make([]Input, len(defs))
+	for i, def := range defs {
+		input, err := New(def.Module, def.Options.Map())
+		if err != nil {
+			return nil, fmt.Errorf("%s: input %s: %v", def.Position(), def.Module, err)
+		}
+		inputs[i] = input
+	}
+	return inputs, nil
+}
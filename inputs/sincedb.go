@@ -0,0 +1,219 @@
+package inputs
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var dataDir = "data"
+
+// SetDataDir sets the directory where inputs persist state between restarts.
+// The directory is created when an input first writes to it.
+func SetDataDir(dir string) {
+	dataDir = dir
+}
+
+// A fileID identifies a file independently of its path so a renamed file
+// is recognized and a replaced file is not.
+type fileID struct {
+	inode  uint64
+	device uint64
+}
+
+type sincedbEntry struct {
+	offset int64
+	path   string
+}
+
+// A sincedb records how far into each file an input has read. It's saved
+// to disk as one line per file: inode, device, byte offset, and last known path.
+type sincedb struct {
+	path    string
+	mu      sync.Mutex
+	entries map[fileID]*sincedbEntry
+	dirty   bool
+}
+
+// defaultSincedbPath returns the state file used for an input reading patterns
+// when no path is configured.
+func defaultSincedbPath(patterns []string) string {
+	h := fnv.New64a()
+	for i, pattern := range patterns {
+		if abs, err := filepath.Abs(pattern); err == nil {
+			pattern = abs
+		}
+		if i > 0 {
+			h.Write([]byte{','})
+		}
+		h.Write([]byte(pattern))
+	}
+	return filepath.Join(dataDir, fmt.Sprintf("sincedb_%x", h.Sum64()))
+}
+
+// openSincedb loads the sincedb saved at path. A missing file results
+// in an empty sincedb.
+func openSincedb(path string) (*sincedb, error) {
+	db := &sincedb{
+		path:    path,
+		entries: make(map[fileID]*sincedbEntry),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return db, nil
+		}
+		return db, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) < 3 {
+			return db, fmt.Errorf("%s:%d: invalid sincedb entry", path, lineNum)
+		}
+
+		var id fileID
+		var entry sincedbEntry
+		id.inode, err = strconv.ParseUint(fields[0], 10, 64)
+		if err == nil {
+			id.device, err = strconv.ParseUint(fields[1], 10, 64)
+		}
+		if err == nil {
+			entry.offset, err = strconv.ParseInt(fields[2], 10, 64)
+		}
+		if err != nil {
+			return db, fmt.Errorf("%s:%d: invalid sincedb entry", path, lineNum)
+		}
+		if len(fields) == 4 {
+			entry.path = fields[3]
+		}
+		db.entries[id] = &entry
+	}
+	return db, scanner.Err()
+}
+
+// get returns the saved offset of file id.
+func (s *sincedb) get(id fileID) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[id]
+	if !exists {
+		return 0, false
+	}
+	return entry.offset, true
+}
+
+// set records offset as the read position of file id which was read from path.
+func (s *sincedb) set(id fileID, offset int64, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[id]
+	if exists && entry.offset == offset && entry.path == path {
+		return
+	}
+	s.entries[id] = &sincedbEntry{offset: offset, path: path}
+	s.dirty = true
+}
+
+// remove forgets file id.
+func (s *sincedb) remove(id fileID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[id]; exists {
+		delete(s.entries, id)
+		s.dirty = true
+	}
+}
+
+// retain forgets all files not in ids.
+func (s *sincedb) retain(ids map[fileID]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.entries {
+		if !ids[id] {
+			delete(s.entries, id)
+			s.dirty = true
+		}
+	}
+}
+
+// save writes the sincedb to disk if it changed since the last save. The file
+// is replaced atomically so a crash never leaves a partial sincedb.
+func (s *sincedb) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	ids := make(fileIDs, 0, len(s.entries))
+	for id := range s.entries {
+		ids = append(ids, id)
+	}
+	sort.Sort(ids)
+
+	var buf []byte
+	for _, id := range ids {
+		entry := s.entries[id]
+		buf = append(buf, fmt.Sprintf("%d %d %d %s\n", id.inode, id.device, entry.offset, entry.path)...)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	s.dirty = false
+	return nil
+}
+
+type fileIDs []fileID
+
+func (f fileIDs) Len() int      { return len(f) }
+func (f fileIDs) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f fileIDs) Less(i, j int) bool {
+	if f[i].device != f[j].device {
+		return f[i].device < f[j].device
+	}
+	return f[i].inode < f[j].inode
+}
@@ -0,0 +1,79 @@
+package inputs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSincedbSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state", "sincedb")
+
+	db, err := openSincedb(path)
+	if err != nil {
+		t.Fatalf("Missing sincedb: %v", err)
+	}
+	db.set(fileID{inode: 5, device: 1}, 100, "/var/log/a.log")
+	db.set(fileID{inode: 2, device: 1}, 7, "/var/log/with space.log")
+	db.set(fileID{inode: 9, device: 2}, 1, "/var/log/removed.log")
+	db.remove(fileID{inode: 9, device: 2})
+	if err := db.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	expected := "2 1 7 /var/log/with space.log\n5 1 100 /var/log/a.log\n"
+	if string(data) != expected {
+		t.Errorf("Expected sincedb file %q, got %q", expected, data)
+	}
+
+	db, err = openSincedb(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset, exists := db.get(fileID{inode: 5, device: 1}); !exists || offset != 100 {
+		t.Errorf("Expected offset 100, got %d (%t)", offset, exists)
+	}
+	if entry := db.entries[fileID{inode: 2, device: 1}]; entry == nil || entry.path != "/var/log/with space.log" {
+		t.Errorf("Path not loaded, got %+v", entry)
+	}
+	if _, exists := db.get(fileID{inode: 9, device: 2}); exists {
+		t.Error("Removed file was saved")
+	}
+	if db.dirty {
+		t.Error("Loaded sincedb is dirty")
+	}
+
+	ioutil.WriteFile(path, []byte("1 2 x /a\n"), 0644)
+	if _, err := openSincedb(path); err == nil {
+		t.Error("Expected error loading an invalid sincedb")
+	}
+}
+
+func TestSincedbRetain(t *testing.T) {
+	db, _ := openSincedb(filepath.Join(os.TempDir(), "spartan-nonexistent-sincedb"))
+	db.set(fileID{inode: 1}, 10, "a")
+	db.set(fileID{inode: 2}, 20, "b")
+	db.set(fileID{inode: 3}, 30, "c")
+	db.dirty = false
+
+	db.retain(map[fileID]bool{{inode: 1}: true, {inode: 3}: true})
+	if _, exists := db.get(fileID{inode: 2}); exists {
+		t.Error("Unmatched file was retained")
+	}
+	if _, exists := db.get(fileID{inode: 3}); !exists || !db.dirty {
+		t.Error("Expected matched files retained and the sincedb dirty")
+	}
+
+	db.dirty = false
+	db.retain(map[fileID]bool{{inode: 1}: true, {inode: 3}: true})
+	if db.dirty {
+		t.Error("Retaining all files made the sincedb dirty")
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package inputs
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIDSupported is true if files can be identified and read positions saved.
+const fileIDSupported = true
+
+// getFileID returns the inode and device of the file described by info.
+func getFileID(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{
+		inode:  uint64(stat.Ino),
+		device: uint64(stat.Dev),
+	}, true
+}
@@ -0,0 +1,11 @@
+package inputs
+
+import "os"
+
+// fileIDSupported is true if files can be identified and read positions saved.
+const fileIDSupported = false
+
+// getFileID isn't supported on Windows, read positions aren't persisted.
+func getFileID(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
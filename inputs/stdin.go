@@ -0,0 +1,154 @@
+package inputs
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("stdin", newStdinInput)
+}
+
+type stdinConfig struct {
+	codec     *codecs.Def
+	delimiter []byte
+}
+
+// A StdinInput reads frames from standard input separated by a delimiter, a newline
+// by default. Each frame is decoded with the configured codec, or used as the message
+// if no codec is set. Events have the field host set to the local hostname. The input
+// finishes when standard input is closed.
+type StdinInput struct {
+	config *stdinConfig
+	host   string
+	stdin  io.Reader
+	t      tomb.Tomb
+	out    chan<- *event.Event
+	done   chan struct{}
+}
+
+func newStdinInput(options map[string]interface{}) (Input, error) {
+	i := &StdinInput{
+		config: &stdinConfig{},
+		host:   hostname(),
+		stdin:  os.Stdin,
+		done:   make(chan struct{}),
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *StdinInput) setConfig(options map[string]interface{}) error {
+	codec, err := codecOption(options)
+	if err != nil {
+		return err
+	}
+	i.config.codec = codec
+
+	i.config.delimiter, err = delimiterOption(options, codec)
+	return err
+}
+
+// Start reading standard input.
+func (i *StdinInput) Start(out chan<- *event.Event) error {
+	i.out = out
+	i.t.Go(i.run)
+	return nil
+}
+
+// Close stops reading standard input.
+func (i *StdinInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+// Done is closed once standard input is closed and all events have been sent.
+func (i *StdinInput) Done() <-chan struct{} {
+	return i.done
+}
+
+func (i *StdinInput) run() error {
+	// Reading stdin can't be interrupted, the reader is left blocked
+	// if the input is closed before the end of input.
+	frames := make(chan []byte)
+	go i.read(frames)
+
+	codec := newCodec(i.config.codec)
+	flusher, _ := codec.(codecs.Flusher)
+
+	var flush <-chan time.Time
+	for {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				if flusher != nil {
+					i.send(flusher.Flush())
+				}
+				utils.Log.Infof("Stdin input: end of input")
+				close(i.done)
+				return nil
+			}
+
+			if len(frame) == 0 {
+				continue
+			}
+
+			e, err := decodeFrame(codec, frame)
+			if err != nil {
+				utils.Log.Errorf("Stdin input: %v", err)
+				continue
+			}
+			i.send(e)
+
+			if flusher != nil && flusher.FlushInterval() > 0 {
+				flush = time.After(flusher.FlushInterval())
+			}
+		case <-flush:
+			i.send(flusher.Flush())
+		case <-i.t.Dying():
+			return nil
+		}
+	}
+}
+
+// read sends frames from standard input to frames until the end of input
+// or the input is closed.
+func (i *StdinInput) read(frames chan<- []byte) {
+	defer close(frames)
+	r := newFrameReader(i.stdin, i.config.delimiter, newCodec(i.config.codec))
+
+	for {
+		frame, err := r.next()
+		if err != nil {
+			if err != io.EOF {
+				utils.Log.Errorf("Stdin input: %v", err)
+			}
+			frame = r.rest() // The last frame may not have a delimiter
+			if len(frame) == 0 {
+				return
+			}
+		}
+
+		select {
+		case frames <- frame:
+		case <-i.t.Dying():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (i *StdinInput) send(e *event.Event) {
+	if e == nil {
+		return
+	}
+	e.Set("host", i.host)
+	i.out <- e
+}
@@ -0,0 +1,58 @@
+package inputs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestStdinInputEOF(t *testing.T) {
+	pf, err := parser.ParseString(`input {
+	stdin {
+		codec => multiline {
+			pattern => "^\s"
+			what => "previous"
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := newStdinInput(pf.Inputs[0].Options.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := i.(*StdinInput)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	input.stdin = r
+
+	out := make(chan *event.Event, 10)
+	if err := input.Start(out); err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+
+	w.Write([]byte("a\n  b\nc\n  d\n"))
+	if e := receiveEvent(t, out); e.GetMessage() != "a\n  b" {
+		t.Errorf("Expected message %q, got %q", "a\n  b", e.GetMessage())
+	}
+	w.Close()
+
+	// The pending event is flushed at the end of input
+	if e := receiveEvent(t, out); e.GetMessage() != "c\n  d" {
+		t.Errorf("Expected flushed message %q, got %q", "c\n  d", e.GetMessage())
+	}
+	select {
+	case <-input.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Done wasn't closed at the end of input")
+	}
+}
@@ -0,0 +1,278 @@
+package inputs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("syslog", newSyslogInput)
+}
+
+// maxSyslogMessage is the largest message accepted over UDP.
+const maxSyslogMessage = 65535
+
+type syslogConfig struct {
+	udp      []string
+	tcp      []string
+	timezone *time.Location
+}
+
+// A SyslogInput receives syslog messages over UDP and TCP. Messages may be in
+// RFC 5424 or RFC 3164 format. TCP messages are separated by newlines or use
+// octet counting as described in RFC 6587. Parsed header fields are set on the
+// event: priority, facility, severity, facility_label, severity_label, host,
+// program, pid, msgid, and structured_data. If the header has no hostname, host
+// is the sender's address. Messages that can't be parsed are sent unchanged with
+// the tag _syslogparsefailure.
+type SyslogInput struct {
+	config    *syslogConfig
+	t         tomb.Tomb
+	out       chan<- *event.Event
+	conns     []net.PacketConn
+	listeners []net.Listener
+	closers   closerSet // Listeners and open connections
+}
+
+func newSyslogInput(options map[string]interface{}) (Input, error) {
+	i := &SyslogInput{
+		config: &syslogConfig{
+			timezone: time.Local,
+		},
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *SyslogInput) setConfig(options map[string]interface{}) error {
+	if s, exists := options["udp"]; exists {
+		addrs, ok := stringSlice(s)
+		if !ok {
+			return errors.New("udp must be a string or array of strings")
+		}
+		i.config.udp = addrs
+	}
+
+	if s, exists := options["tcp"]; exists {
+		addrs, ok := stringSlice(s)
+		if !ok {
+			return errors.New("tcp must be a string or array of strings")
+		}
+		i.config.tcp = addrs
+	}
+
+	if len(i.config.udp) == 0 && len(i.config.tcp) == 0 {
+		return errors.New("udp or tcp option required")
+	}
+
+	if s, exists := options["timezone"]; exists {
+		name, ok := s.(string)
+		if !ok {
+			return errors.New("timezone must be a string")
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return fmt.Errorf("Invalid timezone %s", name)
+		}
+		i.config.timezone = loc
+	}
+
+	return nil
+}
+
+// Start listens on the configured addresses. An error is returned if any
+// address can't be listened on.
+func (i *SyslogInput) Start(out chan<- *event.Event) error {
+	i.out = out
+
+	for _, addr := range i.config.udp {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			i.closers.closeAll()
+			return err
+		}
+		i.closers.add(conn)
+		i.conns = append(i.conns, conn)
+	}
+
+	for _, addr := range i.config.tcp {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			i.closers.closeAll()
+			return err
+		}
+		i.closers.add(l)
+		i.listeners = append(i.listeners, l)
+	}
+
+	for _, conn := range i.conns {
+		conn := conn
+		i.t.Go(func() error { return i.readUDP(conn) })
+	}
+	for _, l := range i.listeners {
+		l := l
+		i.t.Go(func() error { return i.acceptTCP(l) })
+	}
+
+	i.t.Go(func() error {
+		// Unblock reads and accepts so all go routines can exit
+		<-i.t.Dying()
+		i.closers.closeAll()
+		return nil
+	})
+	return nil
+}
+
+// Close stops listening and closes all connections.
+func (i *SyslogInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+func (i *SyslogInput) readUDP(conn net.PacketConn) error {
+	utils.Log.Infof("Syslog input: listening on udp %s", conn.LocalAddr())
+	buf := make([]byte, maxSyslogMessage)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-i.t.Dying():
+				return nil
+			default:
+			}
+			utils.Log.Errorf("Syslog input: %v", err)
+			continue
+		}
+		i.send(buf[:n], addr)
+	}
+}
+
+func (i *SyslogInput) acceptTCP(l net.Listener) error {
+	utils.Log.Infof("Syslog input: listening on tcp %s", l.Addr())
+	acceptConns(&i.t, l, &i.closers, "Syslog input", nil, i.readTCP)
+	return nil
+}
+
+// readTCP reads messages from conn until it's closed.
+func (i *SyslogInput) readTCP(conn net.Conn) {
+	frames := newFramerReader(conn, syslogFramer{})
+
+	for {
+		frame, err := frames.next()
+		if err == io.EOF {
+			// The last message may not have a newline
+			rest := frames.rest()
+			if !isOctetCounted(rest) {
+				i.send(rest, conn.RemoteAddr())
+				return
+			}
+			err = errIncompleteFrame
+		}
+		if err != nil {
+			select {
+			case <-i.t.Dying():
+			default:
+				utils.Log.Errorf("Syslog input: %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		if isOctetCounted(frame) {
+			frame = frame[bytes.IndexByte(frame, ' ')+1:]
+		}
+		i.send(frame, conn.RemoteAddr())
+	}
+}
+
+// A syslogFramer splits syslog messages received over TCP. Each message is
+// either newline terminated or prefixed with its length and a space.
+type syslogFramer struct{}
+
+func (syslogFramer) FrameLength(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if !isOctetCounted(data) {
+		return bytes.IndexByte(data, '\n') + 1, nil
+	}
+
+	space := bytes.IndexByte(data, ' ')
+	if space < 0 {
+		if digits := len(strconv.Itoa(maxSyslogMessage)); len(data) > digits {
+			return 0, fmt.Errorf("invalid message length %q", data[:digits+1])
+		}
+		return 0, nil
+	}
+	n, err := strconv.Atoi(string(data[:space]))
+	if err != nil || n < 1 || n > maxSyslogMessage {
+		return 0, fmt.Errorf("invalid message length %q", data[:space])
+	}
+	if len(data) < space+1+n {
+		return 0, nil
+	}
+	return space + 1 + n, nil
+}
+
+// isOctetCounted returns true if data starts with a message length.
+func isOctetCounted(data []byte) bool {
+	return len(data) > 0 && data[0] >= '0' && data[0] <= '9'
+}
+
+// send parses data and sends it as an Event. addr is the sender's address.
+// Empty messages are ignored.
+func (i *SyslogInput) send(data []byte, addr net.Addr) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+	if len(data) == 0 {
+		return
+	}
+
+	host := addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	msg, err := parseSyslog(data, time.Now(), i.config.timezone)
+	if err != nil {
+		e := event.New(string(data))
+		e.Set("host", host)
+		e.AddTag("_syslogparsefailure")
+		i.out <- e
+		return
+	}
+
+	e := event.New(msg.message)
+	e.SetTimestamp(msg.timestamp)
+	e.Set("priority", msg.priority)
+	e.Set("facility", msg.facility())
+	e.Set("severity", msg.severity())
+	e.Set("facility_label", syslogFacilities[msg.facility()])
+	e.Set("severity_label", syslogSeverities[msg.severity()])
+
+	if msg.hostname != "" {
+		host = msg.hostname
+	}
+	e.Set("host", host)
+
+	if msg.program != "" {
+		e.Set("program", msg.program)
+	}
+	if msg.pid != "" {
+		e.Set("pid", msg.pid)
+	}
+	if msg.msgID != "" {
+		e.Set("msgid", msg.msgID)
+	}
+	if len(msg.structuredData) > 0 {
+		e.Set("structured_data", msg.structuredData)
+	}
+	i.out <- e
+}
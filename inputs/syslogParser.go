@@ -0,0 +1,225 @@
+package inputs
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	syslogFacilities = []string{
+		"kernel", "user-level", "mail", "daemon", "security/authorization", "syslogd",
+		"line printer", "network news", "uucp", "clock", "security/authorization",
+		"ftp", "ntp", "log audit", "log alert", "clock", "local0", "local1", "local2",
+		"local3", "local4", "local5", "local6", "local7",
+	}
+
+	syslogSeverities = []string{
+		"emergency", "alert", "critical", "error", "warning", "notice", "informational", "debug",
+	}
+
+	errSyslogPriority      = errors.New("invalid syslog priority")
+	errSyslogHeader        = errors.New("invalid syslog header")
+	errSyslogStructureData = errors.New("invalid syslog structured data")
+)
+
+// A syslogMessage is a message parsed from RFC 3164 or RFC 5424 format.
+// Fields not in the message are left empty.
+type syslogMessage struct {
+	priority       int
+	timestamp      time.Time
+	hostname       string
+	program        string
+	pid            string
+	msgID          string
+	structuredData map[string]map[string]string
+	message        string
+}
+
+func (m *syslogMessage) facility() int { return m.priority / 8 }
+func (m *syslogMessage) severity() int { return m.priority % 8 }
+
+// parseSyslog parses a syslog message in either RFC 5424 or RFC 3164 format.
+// RFC 3164 timestamps don't include a year or timezone, they're assumed to be
+// in loc and in the year of now.
+func parseSyslog(data []byte, now time.Time, loc *time.Location) (*syslogMessage, error) {
+	line := string(bytes.TrimRight(data, "\r\n\x00"))
+	m := &syslogMessage{timestamp: now}
+
+	// <PRI>
+	end := strings.IndexByte(line, '>')
+	if len(line) < 3 || line[0] != '<' || end < 2 || end > 4 {
+		return nil, errSyslogPriority
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, errSyslogPriority
+	}
+	m.priority = pri
+	line = line[end+1:]
+
+	if strings.HasPrefix(line, "1 ") {
+		return m, m.parseRFC5424(line[2:])
+	}
+	m.parseRFC3164(line, now, loc)
+	return m, nil
+}
+
+// parseRFC5424 parses the message after the version number:
+// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func (m *syslogMessage) parseRFC5424(line string) error {
+	header := make([]string, 5)
+	for i := range header {
+		var field string
+		field, line = nextField(line)
+		if field == "" {
+			return errSyslogHeader
+		}
+		if field != "-" {
+			header[i] = field
+		}
+	}
+
+	if header[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, header[0])
+		if err != nil {
+			return errSyslogHeader
+		}
+		m.timestamp = ts
+	}
+	m.hostname = header[1]
+	m.program = header[2]
+	m.pid = header[3]
+	m.msgID = header[4]
+
+	if strings.HasPrefix(line, "-") {
+		line = line[1:]
+	} else {
+		var err error
+		line, err = m.parseStructuredData(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	if line != "" && line[0] != ' ' {
+		return errSyslogHeader
+	}
+	line = strings.TrimPrefix(line, " ")
+	m.message = strings.TrimPrefix(line, "\xEF\xBB\xBF") // UTF-8 BOM
+	return nil
+}
+
+// parseStructuredData parses one or more SD-ELEMENTs in the form
+// [id name="value" ...] and returns the rest of line.
+func (m *syslogMessage) parseStructuredData(line string) (string, error) {
+	m.structuredData = make(map[string]map[string]string)
+
+	for strings.HasPrefix(line, "[") {
+		end := strings.IndexAny(line, " ]")
+		if end < 2 {
+			return "", errSyslogStructureData
+		}
+		params := make(map[string]string)
+		m.structuredData[line[1:end]] = params
+		line = line[end:]
+
+		for line != "" && line[0] == ' ' {
+			eq := strings.Index(line, "=\"")
+			if eq < 2 {
+				return "", errSyslogStructureData
+			}
+			name := line[1:eq]
+			line = line[eq+2:]
+
+			// Values may contain escaped ", \, and ]
+			var value []byte
+			i := 0
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte(`"\]`, line[i+1]) > -1 {
+					i++
+				}
+				value = append(value, line[i])
+			}
+			if i == len(line) {
+				return "", errSyslogStructureData
+			}
+			params[name] = string(value)
+			line = line[i+1:]
+		}
+
+		if !strings.HasPrefix(line, "]") {
+			return "", errSyslogStructureData
+		}
+		line = line[1:]
+	}
+	return line, nil
+}
+
+// parseRFC3164 parses the message after the priority: TIMESTAMP HOSTNAME TAG: MSG.
+// RFC 3164 is only a description of common practice so any part that can't be
+// parsed is treated as part of the message.
+func (m *syslogMessage) parseRFC3164(line string, now time.Time, loc *time.Location) {
+	m.message = line
+
+	// Some senders use RFC 3339 timestamps instead of the BSD format
+	if field, rest := nextField(line); field != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			m.timestamp = ts
+			line = rest
+		} else if len(line) >= len(time.Stamp) {
+			ts, err := time.ParseInLocation(time.Stamp, line[:len(time.Stamp)], loc)
+			if err != nil {
+				return
+			}
+			m.timestamp = closestYear(ts, now)
+			line = strings.TrimPrefix(line[len(time.Stamp):], " ")
+		} else {
+			return
+		}
+	}
+	m.message = line
+
+	// The hostname may be missing, a tag ends with a colon or bracket
+	field, rest := nextField(line)
+	if field != "" && !strings.HasSuffix(field, ":") && !strings.HasSuffix(field, "]") {
+		m.hostname = field
+		line = rest
+		m.message = line
+	}
+
+	field, rest = nextField(line)
+	tag := strings.TrimSuffix(field, ":")
+	if tag == field && !strings.HasSuffix(field, "]") {
+		return // Not a tag
+	}
+	if start := strings.IndexByte(tag, '['); start > 0 && strings.HasSuffix(tag, "]") {
+		m.pid = tag[start+1 : len(tag)-1]
+		tag = tag[:start]
+	}
+	m.program = tag
+	m.message = strings.TrimPrefix(rest, ":")
+	m.message = strings.TrimPrefix(m.message, " ")
+}
+
+// closestYear sets the year of ts to the year of now unless that puts ts more
+// than a day in the future. Then it's a message sent before the new year.
+func closestYear(ts, now time.Time) time.Time {
+	ts = ts.AddDate(now.Year()-ts.Year(), 0, 0)
+	if ts.Sub(now) > 24*time.Hour {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts
+}
+
+// nextField returns the text before the first space in s, and the rest of s
+// after the space.
+func nextField(s string) (string, string) {
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
@@ -0,0 +1,108 @@
+package inputs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSyslog(t *testing.T) {
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		line     string
+		expected *syslogMessage
+	}{
+		{
+			line: "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8\n",
+			expected: &syslogMessage{
+				priority:  34,
+				timestamp: time.Date(2017, 10, 11, 22, 14, 15, 0, time.UTC),
+				hostname:  "mymachine",
+				program:   "su",
+				pid:       "123",
+				message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			line: "<13>Dec 31 23:59:59 kernel: year boundary",
+			expected: &syslogMessage{
+				priority:  13,
+				timestamp: time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC),
+				program:   "kernel",
+				message:   "year boundary",
+			},
+		},
+		{
+			line: "<13>no header at all",
+			expected: &syslogMessage{
+				priority:  13,
+				timestamp: now,
+				message:   "no header at all",
+			},
+		},
+		{
+			line: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high \"x\""] An application event`,
+			expected: &syslogMessage{
+				priority:  165,
+				timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				hostname:  "mymachine.example.com",
+				program:   "evntslog",
+				msgID:     "ID47",
+				structuredData: map[string]map[string]string{
+					"exampleSDID@32473": {
+						"iut":         "3",
+						"eventSource": "Application",
+						"eventID":     "1011",
+					},
+					"examplePriority@32473": {
+						"class": `high "x"`,
+					},
+				},
+				message: "An application event",
+			},
+		},
+		{
+			line: "<34>1 - - su 123 - - \xEF\xBB\xBFsu failed",
+			expected: &syslogMessage{
+				priority:  34,
+				timestamp: now,
+				program:   "su",
+				pid:       "123",
+				message:   "su failed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		msg, err := parseSyslog([]byte(test.line), now, time.UTC)
+		if err != nil {
+			t.Errorf("%q: %v", test.line, err)
+			continue
+		}
+		if !msg.timestamp.Equal(test.expected.timestamp) {
+			t.Errorf("%q: expected timestamp %s, got %s", test.line, test.expected.timestamp, msg.timestamp)
+		}
+		msg.timestamp = test.expected.timestamp
+		if !reflect.DeepEqual(msg, test.expected) {
+			t.Errorf("%q: expected %#v, got %#v", test.line, test.expected, msg)
+		}
+	}
+}
+
+func TestParseSyslogErrors(t *testing.T) {
+	lines := []string{
+		"no priority",
+		"<192>too high",
+		"<abc>not a number",
+		"<34>1 2003-10-11T22:14:15.003Z host app",
+		"<34>1 notatime host app - - - message",
+		`<34>1 - host app - - [id key="unterminated] message`,
+	}
+
+	for _, line := range lines {
+		if _, err := parseSyslog([]byte(line), time.Now(), time.UTC); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package inputs
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestSyslogFramer(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected int
+	}{
+		{"", 0},
+		{"<13>no newline", 0},
+		{"<13>one\n<13>two\n", 8},
+		{"12 <13>message", 0},
+		{"12 <13>message\n", 15},
+		{"12", 0},
+	}
+	for _, test := range tests {
+		n, err := syslogFramer{}.FrameLength([]byte(test.data))
+		if err != nil {
+			t.Errorf("%q: %v", test.data, err)
+		} else if n != test.expected {
+			t.Errorf("%q: expected length %d, got %d", test.data, test.expected, n)
+		}
+	}
+
+	for _, data := range []string{"0 ", "99999999", "70000 <13>", "1a <13>"} {
+		if _, err := (syslogFramer{}).FrameLength([]byte(data)); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
+
+func TestSyslogInput(t *testing.T) {
+	i, err := newSyslogInput(map[string]interface{}{
+		"udp": "127.0.0.1:0",
+		"tcp": "127.0.0.1:0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := i.(*SyslogInput)
+	out := make(chan *event.Event, 10)
+	if err := input.Start(out); err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+
+	rfc3164 := "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed"
+	rfc5424 := `<165>1 2003-10-11T22:14:15.003Z web1 evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+
+	conn, err := net.Dial("tcp", input.listeners[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte(rfc3164 + "\n"))
+	conn.Write([]byte("101 " + rfc5424))
+	conn.Write([]byte("<13>no newline"))
+	conn.Close()
+
+	e := receiveEvent(t, out)
+	if e.GetMessage() != "'su root' failed" || e.Get("host") != "mymachine" || e.Get("program") != "su" || e.Get("pid") != "123" {
+		t.Errorf("Unexpected RFC 3164 event %v", e.Squash().Map())
+	}
+	e = receiveEvent(t, out)
+	if e.GetMessage() != "An application event" || e.Get("host") != "web1" || e.Get("msgid") != "ID47" || e.Get("severity") != 5 {
+		t.Errorf("Unexpected RFC 5424 event %v", e.Squash().Map())
+	}
+	if e := receiveEvent(t, out); e.GetMessage() != "no newline" || e.Get("host") != "127.0.0.1" {
+		t.Errorf("Expected message %q from 127.0.0.1, got %q from %v", "no newline", e.GetMessage(), e.Get("host"))
+	}
+
+	udp, err := net.Dial("udp", input.conns[0].LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	udp.Write([]byte(rfc5424))
+	if e := receiveEvent(t, out); e.GetMessage() != "An application event" || e.Get("program") != "evntslog" {
+		t.Errorf("Unexpected UDP event %v", e.Squash().Map())
+	}
+}
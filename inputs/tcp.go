@@ -0,0 +1,166 @@
+package inputs
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("tcp", newTCPInput)
+}
+
+type tcpConfig struct {
+	networkConfig
+	maxConnections int
+}
+
+// A TCPInput accepts connections on an address and reads frames separated by a
+// delimiter, a newline by default. Each frame is decoded with the configured codec,
+// or used as the message if no codec is set. Events have the fields host and port
+// set to the remote address of the connection. If max_connections is set, new
+// connections are closed while that many connections are open.
+type TCPInput struct {
+	config      *tcpConfig
+	t           tomb.Tomb
+	out         chan<- *event.Event
+	listener    net.Listener
+	closers     closerSet
+	connections int32
+}
+
+func newTCPInput(options map[string]interface{}) (Input, error) {
+	i := &TCPInput{
+		config: &tcpConfig{},
+	}
+	return i, i.setConfig(options)
+}
+
+func (i *TCPInput) setConfig(options map[string]interface{}) error {
+	if err := i.config.networkConfig.setConfig(options); err != nil {
+		return err
+	}
+
+	if s, exists := options["max_connections"]; exists {
+		max, ok := s.(int)
+		if !ok || max < 1 {
+			return errors.New("max_connections must be a positive integer")
+		}
+		i.config.maxConnections = max
+	}
+
+	return nil
+}
+
+// Start listens on the configured address.
+func (i *TCPInput) Start(out chan<- *event.Event) error {
+	i.out = out
+
+	l, err := net.Listen("tcp", i.config.address)
+	if err != nil {
+		return err
+	}
+	i.listener = l
+	i.closers.add(l)
+
+	i.t.Go(func() error { return i.accept(l) })
+	i.t.Go(func() error {
+		<-i.t.Dying()
+		i.closers.closeAll()
+		return nil
+	})
+	return nil
+}
+
+// Close stops listening and closes all connections.
+func (i *TCPInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+func (i *TCPInput) accept(l net.Listener) error {
+	utils.Log.Infof("TCP input: listening on %s", l.Addr())
+	acceptConns(&i.t, l, &i.closers, "TCP input", i.allow, func(conn net.Conn) {
+		i.read(conn)
+		atomic.AddInt32(&i.connections, -1)
+	})
+	return nil
+}
+
+// allow counts conn as open, or returns false if max_connections are open.
+func (i *TCPInput) allow(conn net.Conn) bool {
+	if i.config.maxConnections > 0 && int(atomic.LoadInt32(&i.connections)) >= i.config.maxConnections {
+		utils.Log.Warningf("TCP input: connection limit reached, closing connection from %s", conn.RemoteAddr())
+		return false
+	}
+	atomic.AddInt32(&i.connections, 1)
+	return true
+}
+
+// read decodes frames from conn until it's closed. If the codec is a Flusher,
+// held data is flushed when no data is received for the codec's flush interval
+// and when the connection is closed.
+func (i *TCPInput) read(conn net.Conn) {
+	codec := newCodec(i.config.codec)
+	flusher, _ := codec.(codecs.Flusher)
+	frames := newFrameReader(conn, i.config.delimiter, codec)
+
+	for {
+		if flusher != nil && flusher.FlushInterval() > 0 {
+			conn.SetReadDeadline(time.Now().Add(flusher.FlushInterval()))
+		}
+
+		frame, err := frames.next()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				i.send(flusher.Flush(), conn.RemoteAddr())
+				continue
+			}
+
+			// The last frame may not have a delimiter
+			if err == io.EOF {
+				i.decode(codec, frames.rest(), conn.RemoteAddr())
+			} else {
+				select {
+				case <-i.t.Dying():
+				default:
+					utils.Log.Errorf("TCP input: %s: %v", conn.RemoteAddr(), err)
+				}
+			}
+			if flusher != nil {
+				i.send(flusher.Flush(), conn.RemoteAddr())
+			}
+			return
+		}
+
+		i.decode(codec, frame, conn.RemoteAddr())
+	}
+}
+
+// decode sends the Event decoded from frame. Empty frames are ignored.
+func (i *TCPInput) decode(codec codecs.Codec, frame []byte, addr net.Addr) {
+	if len(frame) == 0 {
+		return
+	}
+	e, err := decodeFrame(codec, frame)
+	if err != nil {
+		utils.Log.Errorf("TCP input: %s: %v", addr, err)
+		return
+	}
+	i.send(e, addr)
+}
+
+func (i *TCPInput) send(e *event.Event, addr net.Addr) {
+	if e == nil {
+		return
+	}
+	setSourceFields(e, addr)
+	i.out <- e
+}
@@ -0,0 +1,95 @@
+package inputs
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+// receiveEvent returns the next Event from out or fails if none is sent in time.
+func receiveEvent(t *testing.T, out <-chan *event.Event) *event.Event {
+	select {
+	case e := <-out:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return nil
+}
+
+func TestTCPInput(t *testing.T) {
+	pf, err := parser.ParseString(`input {
+	tcp {
+		address => "127.0.0.1:0"
+		max_connections => 1
+		codec => multiline {
+			pattern => "^\s"
+			what => "previous"
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := newTCPInput(pf.Inputs[0].Options.Map())
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := i.(*TCPInput)
+	out := make(chan *event.Event, 10)
+	if err := input.Start(out); err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+	addr := input.listener.Addr().String()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("a\n  b\nc\n  d"))
+	if e := receiveEvent(t, out); e.GetMessage() != "a\n  b" || e.Get("host") != "127.0.0.1" {
+		t.Errorf("Expected message %q from 127.0.0.1, got %q from %v", "a\n  b", e.GetMessage(), e.Get("host"))
+	}
+
+	// A second connection is closed while the first is open
+	extra, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer extra.Close()
+	extra.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := extra.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected connection over the limit to be closed, got %v", err)
+	}
+
+	// The last frame and the event held by the codec are flushed on close
+	conn.Close()
+	if e := receiveEvent(t, out); e.GetMessage() != "c\n  d" {
+		t.Errorf("Expected flushed message %q, got %q", "c\n  d", e.GetMessage())
+	}
+
+	// A new connection is accepted once the first is closed
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&input.connections) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Connection wasn't released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn, err = net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("e\n"))
+	conn.Close()
+	if e := receiveEvent(t, out); e.GetMessage() != "e" {
+		t.Errorf("Expected message e, got %q", e.GetMessage())
+	}
+}
@@ -0,0 +1,108 @@
+package inputs
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+	tomb "gopkg.in/tomb.v2"
+)
+
+func init() {
+	register("udp", newUDPInput)
+}
+
+// maxDatagramSize is the largest UDP datagram accepted.
+const maxDatagramSize = 65535
+
+// A UDPInput receives datagrams on an address. Each datagram is split into frames
+// separated by a delimiter, a newline by default. Each frame is decoded with the
+// configured codec, or used as the message if no codec is set. Events have the
+// fields host and port set to the sender's address.
+type UDPInput struct {
+	config *networkConfig
+	t      tomb.Tomb
+	out    chan<- *event.Event
+	conn   net.PacketConn
+}
+
+func newUDPInput(options map[string]interface{}) (Input, error) {
+	i := &UDPInput{
+		config: &networkConfig{},
+	}
+	if err := i.config.setConfig(options); err != nil {
+		return nil, err
+	}
+
+	// Datagrams may come from any number of senders so the codec
+	// can't hold data between datagrams.
+	if _, ok := newCodec(i.config.codec).(codecs.Flusher); ok {
+		return nil, fmt.Errorf("codec %s combines frames and can't be used with udp", i.config.codec.Name)
+	}
+	return i, nil
+}
+
+// Start listens on the configured address.
+func (i *UDPInput) Start(out chan<- *event.Event) error {
+	i.out = out
+
+	conn, err := net.ListenPacket("udp", i.config.address)
+	if err != nil {
+		return err
+	}
+	i.conn = conn
+
+	i.t.Go(i.run)
+	i.t.Go(func() error {
+		<-i.t.Dying()
+		i.conn.Close()
+		return nil
+	})
+	return nil
+}
+
+// Close stops listening.
+func (i *UDPInput) Close() error {
+	i.t.Kill(nil)
+	return i.t.Wait()
+}
+
+func (i *UDPInput) run() error {
+	utils.Log.Infof("UDP input: listening on %s", i.conn.LocalAddr())
+
+	codec := newCodec(i.config.codec)
+	buf := make([]byte, maxDatagramSize)
+
+	for {
+		n, addr, err := i.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-i.t.Dying():
+				return nil
+			default:
+			}
+			utils.Log.Errorf("UDP input: %v", err)
+			continue
+		}
+
+		frames, err := splitFrames(buf[:n], i.config.delimiter, codec)
+		if err != nil {
+			utils.Log.Errorf("UDP input: %s: %v", addr, err)
+			continue
+		}
+		for _, frame := range frames {
+			e, err := decodeFrame(codec, frame)
+			if err != nil {
+				utils.Log.Errorf("UDP input: %s: %v", addr, err)
+				continue
+			}
+			if e == nil {
+				continue
+			}
+			setSourceFields(e, addr)
+			i.out <- e
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package outputs
+
+import (
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+// A conditional implements an if statement in an output pipeline. Each event in a
+// batch is sent to the true or false branch depending on the condition. Once both
+// branches have run, the full batch is sent to the next Output.
+type conditional struct {
+	condition parser.Condition
+	ifTrue    Output
+	ifFalse   Output
+	next      Output
+}
+
+func newConditional(c parser.Condition) *conditional {
+	return &conditional{condition: c}
+}
+
+// SetNext sets the next Output in line.
+func (o *conditional) SetNext(next Output) {
+	o.next = next
+}
+
+// Run processes a batch.
+func (o *conditional) Run(batch []*event.Event) {
+	trueBatch := make([]*event.Event, 0, len(batch))
+	falseBatch := make([]*event.Event, 0, len(batch))
+
+	for _, event := range batch {
+		if event == nil {
+			continue
+		}
+
+		if o.condition.Evaluate(event) {
+			trueBatch = append(trueBatch, event)
+		} else {
+			falseBatch = append(falseBatch, event)
+		}
+	}
+
+	if len(trueBatch) > 0 {
+		o.ifTrue.Run(trueBatch)
+	}
+	if len(falseBatch) > 0 {
+		o.ifFalse.Run(falseBatch)
+	}
+
+	o.next.Run(batch)
+}
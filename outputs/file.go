@@ -0,0 +1,142 @@
+package outputs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
+)
+
+func init() {
+	register("file", newFileOutput)
+}
+
+type fileConfig struct {
+	path  string
+	codec codecs.Codec
+
+	// prefix is the text of path before the first reference and dir is the
+	// directory of prefix. Files must be in dir.
+	prefix string
+	dir    string
+}
+
+// FileOutput appends events to files. The path may contain %{field} and
+// %{+YYYY.MM.dd} references so events are written to files by day, type, etc.
+// Missing directories are created. Events whose path contains ".." or is outside
+// the directory of the path's text before the first reference are dropped so
+// event data can't write to other files.
+type FileOutput struct {
+	config *fileConfig
+	next   Output
+}
+
+func newFileOutput(options map[string]interface{}) (Output, error) {
+	options = checkOptionsMap(options)
+	o := &FileOutput{config: &fileConfig{}}
+	if err := o.setConfig(options); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *FileOutput) setConfig(options map[string]interface{}) error {
+	if s, exists := options["path"]; exists {
+		path, ok := s.(string)
+		if !ok || path == "" {
+			return errors.New("Path must be a non-empty string")
+		}
+		o.config.path = path
+		o.config.prefix = path
+		if i := strings.Index(path, "%{"); i >= 0 {
+			o.config.prefix = path[:i]
+		}
+		o.config.dir = filepath.Dir(o.config.prefix)
+	} else {
+		return errors.New("Path option required")
+	}
+
+	codec, err := codecOption(options)
+	if err != nil {
+		return err
+	}
+	o.config.codec = codec
+	return nil
+}
+
+// SetNext sets the next Output in line.
+func (o *FileOutput) SetNext(next Output) {
+	o.next = next
+}
+
+// Run processes a batch.
+func (o *FileOutput) Run(batch []*event.Event) {
+	// Collect the batch by file so each file is opened once
+	files := make(map[string]*bytes.Buffer)
+	var paths []string
+
+	for _, event := range batch {
+		if event == nil {
+			continue
+		}
+
+		path := event.Sprintf(o.config.path)
+		if !o.safePath(path) {
+			utils.Log.Warningf("File output: dropped event with path %s outside of %s", path, o.config.dir)
+			continue
+		}
+
+		buf, exists := files[path]
+		if !exists {
+			buf = &bytes.Buffer{}
+			files[path] = buf
+			paths = append(paths, path)
+		}
+
+		buf.Write(encodeLine(o.config.codec, event))
+	}
+
+	for _, path := range paths {
+		if err := appendFile(path, files[path].Bytes()); err != nil {
+			utils.Log.Errorf("File output: %v", err)
+		}
+	}
+	o.next.Run(batch)
+}
+
+// safePath checks path, the path option with references replaced, doesn't have
+// a ".." element after the text before the first reference and is in its directory.
+func (o *FileOutput) safePath(path string) bool {
+	if path == o.config.path {
+		return true
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(path[len(o.config.prefix):]), "/") {
+		if elem == ".." {
+			return false
+		}
+	}
+
+	rel, err := filepath.Rel(o.config.dir, filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func appendFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,149 @@
+package outputs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
+	"github.com/lfkeitel/spartan/event"
+)
+
+func TestFileOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o, err := newFileOutput(map[string]interface{}{
+		"path":  filepath.Join(dir, "%{type}", "%{+YYYY-MM-dd}.log"),
+		"codec": "line",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetNext(&End{})
+
+	day := time.Date(2017, time.March, 15, 10, 0, 0, 0, time.UTC)
+	var batch []*event.Event
+	for i, etype := range []string{"app", "web", "app"} {
+		e := event.New(etype + strconv.Itoa(i+1))
+		e.SetType(etype)
+		e.SetTimestamp(day)
+		batch = append(batch, e)
+	}
+	o.Run(batch)
+	o.Run(batch[:1])
+
+	expected := map[string]string{
+		"app/2017-03-15.log": "app1\napp3\napp1\n",
+		"web/2017-03-15.log": "web2\n",
+	}
+	for name, content := range expected {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, data)
+		}
+	}
+}
+
+func TestFileOutputUnsafePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o, err := newFileOutput(map[string]interface{}{
+		"path": filepath.Join(dir, "logs", "%{type}.log"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetNext(&End{})
+
+	var batch []*event.Event
+	for _, etype := range []string{"../escaped", "a/../../escaped", "..", "app"} {
+		e := event.New("")
+		e.SetType(etype)
+		batch = append(batch, e)
+	}
+	o.Run(batch)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || files[0] != filepath.Join(dir, "logs") {
+		t.Errorf("Expected only the logs directory, got %v", files)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logs", "app.log")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileOutputBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.msgpack")
+	o, err := newFileOutput(map[string]interface{}{
+		"path":  path,
+		"codec": "msgpack",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetNext(&End{})
+
+	e := event.New("hello")
+	o.Run([]*event.Event{e})
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := codecs.New("msgpack", nil)
+	if expected := c.Encode(e); string(data) != string(expected) {
+		t.Errorf("Expected % x, got % x", expected, data)
+	}
+}
+
+func TestFileOutputDelimited(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spartan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.log")
+	pf, err := parser.ParseString(`output { file { codec => line { delimiter => "|" } } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := pf.Outputs[0].Options.Map()
+	options["path"] = path
+	o, err := newFileOutput(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.SetNext(&End{})
+	o.Run([]*event.Event{event.New("a"), event.New("b")})
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a|b|" {
+		t.Errorf("Expected %q, got %q", "a|b|", data)
+	}
+}
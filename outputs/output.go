@@ -1,8 +1,11 @@
 package outputs
 
 import (
+	"bytes"
 	"errors"
 
+	"github.com/lfkeitel/spartan/codecs"
+	"github.com/lfkeitel/spartan/config/parser"
 	"github.com/lfkeitel/spartan/event"
 )
 
@@ -34,6 +37,37 @@ func register(name string, init initFunc) {
 	registeredOutputInits[name] = init
 }
 
+// codecOption returns the codec given in the codec option, or the json
+// codec if the option isn't set.
+func codecOption(options map[string]interface{}) (codecs.Codec, error) {
+	s, exists := options["codec"]
+	if !exists {
+		return codecs.New("json", nil)
+	}
+	name, codecOptions, ok := parser.ModuleOption(s)
+	if !ok {
+		return nil, errors.New("codec must be a codec name or definition")
+	}
+	def, err := codecs.ParseDef(name, codecOptions)
+	if err != nil {
+		return nil, err
+	}
+	return def.New()
+}
+
+// encodeLine encodes e with codec followed by a newline if the codec didn't add
+// one. Binary and Delimited codecs frame their own data and are returned as is.
+func encodeLine(codec codecs.Codec, e *event.Event) []byte {
+	encoded := codec.Encode(e)
+	if _, ok := codec.(codecs.Delimited); ok {
+		return encoded
+	}
+	if codecs.IsBinary(codec) || bytes.HasSuffix(encoded, []byte{'\n'}) {
+		return encoded
+	}
+	return append(encoded, '\n')
+}
+
 // New creates an instance of Output name with options. Options are dependent on the Output.
 func New(name string, options map[string]interface{}) (Output, error) {
 	init, exists := registeredOutputInits[name]
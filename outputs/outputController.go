@@ -1,9 +1,10 @@
 package outputs
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/lfkeitel/spartan/event"
+	"github.com/lfkeitel/spartan/utils"
 
 	tomb "gopkg.in/tomb.v2"
 )
@@ -12,19 +13,23 @@ import (
 // pipelines and starting a chain of Outputs to process the batch. Events are
 // considered process once they've been through the output chain.
 type OutputController struct {
-	start     Output
-	batchSize int
-	t         tomb.Tomb
-	in        <-chan *event.Event
-	out       chan<- *event.Event
+	start         Output
+	batchSize     int
+	flushInterval time.Duration
+	t             tomb.Tomb
+	in            <-chan *event.Event
+	out           chan<- *event.Event
 }
 
 // NewOutputController creates a new controller using start as the root Output
-// and batchSize as the number of events to queue before processing.
-func NewOutputController(start Output, batchSize int) *OutputController {
+// and batchSize as the number of events to queue before processing. A partial
+// batch is processed once flushInterval has passed since its first event arrived.
+// A flushInterval of 0 disables time based flushing.
+func NewOutputController(start Output, batchSize int, flushInterval time.Duration) *OutputController {
 	return &OutputController{
-		start:     start,
-		batchSize: batchSize,
+		start:         start,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
 	}
 }
 
@@ -46,33 +51,15 @@ func (o *OutputController) Close() error {
 }
 
 func (o *OutputController) run() error {
-	fmt.Println("Output Pipeline started")
+	utils.Log.Infof("Output pipeline started")
 	for {
-		select {
-		case <-o.t.Dying():
-			return nil
-		default:
-		}
+		batch, stopping := event.CollectBatch(o.in, o.batchSize, o.flushInterval, o.t.Dying())
 
-		currentBatch := 0
-		batch := make([]*event.Event, o.batchSize)
-		stopping := false
-
-	CURRENT:
-		for currentBatch < o.batchSize {
-			select {
-			case event := <-o.in:
-				batch[currentBatch] = event
-				currentBatch++
-			case <-o.t.Dying():
-				stopping = true
-				break CURRENT
-			}
+		if len(batch) > 0 {
+			utils.Log.Debugf("Processing output batch of %d events", len(batch))
+			o.start.Run(batch)
 		}
 
-		fmt.Println("Processing batch")
-		o.start.Run(batch)
-
 		if stopping {
 			return nil
 		}
@@ -0,0 +1,60 @@
+package outputs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lfkeitel/spartan/event"
+)
+
+// batchOutput sends each batch it runs to a channel.
+type batchOutput struct {
+	batches chan []*event.Event
+}
+
+func (o *batchOutput) SetNext(next Output) {}
+
+func (o *batchOutput) Run(batch []*event.Event) {
+	o.batches <- batch
+}
+
+func TestOutputControllerFlush(t *testing.T) {
+	out := &batchOutput{batches: make(chan []*event.Event, 1)}
+	controller := NewOutputController(out, 10, 20*time.Millisecond)
+
+	in := make(chan *event.Event)
+	controller.Start(in)
+	defer controller.Close()
+
+	in <- event.New("one")
+	in <- nil
+	in <- event.New("two")
+
+	select {
+	case batch := <-out.batches:
+		if len(batch) != 2 {
+			t.Fatalf("Incorrect batch len. Expected 2, got %d", len(batch))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Partial batch wasn't flushed")
+	}
+}
+
+func TestOutputControllerCloseFlush(t *testing.T) {
+	out := &batchOutput{batches: make(chan []*event.Event, 1)}
+	controller := NewOutputController(out, 10, 0)
+
+	in := make(chan *event.Event)
+	controller.Start(in)
+	in <- event.New("one")
+	controller.Close()
+
+	select {
+	case batch := <-out.batches:
+		if len(batch) != 1 {
+			t.Fatalf("Incorrect batch len. Expected 1, got %d", len(batch))
+		}
+	default:
+		t.Fatal("In-flight batch wasn't processed on close")
+	}
+}
@@ -0,0 +1,60 @@
+package outputs
+
+import (
+	"fmt"
+
+	"github.com/lfkeitel/spartan/config/parser"
+)
+
+// GeneratePipeline creates the Output instances described by defs and connects
+// them as described by their Connections. The returned Output is the root of the
+// pipeline. An empty defs slice results in a pipeline with only an End output.
+func GeneratePipeline(defs []*parser.PipelineDef) (Output, error) {
+	end, _ := New("end", nil)
+	if len(defs) == 0 {
+		return end, nil
+	}
+
+	outputs := make([]Output, len(defs))
+	for i, def := range defs {
+		if def.Condition != nil {
+			outputs[i] = newConditional(def.Condition)
+			continue
+		}
+
+		output, err := New(def.Module, def.Options.Map())
+		if err != nil {
+			return nil, fmt.Errorf("%s: output %s: %v", def.Position(), def.Module, err)
+		}
+		outputs[i] = output
+	}
+
+	// outputAt returns the End output for connections that don't exist
+	outputAt := func(i int) Output {
+		if i < 0 {
+			return end
+		}
+		return outputs[i]
+	}
+
+	for i, def := range defs {
+		switch len(def.Connections) {
+		case 0:
+			outputs[i].SetNext(end)
+		case 1:
+			outputs[i].SetNext(outputAt(def.Connections[0]))
+		case 3:
+			c, ok := outputs[i].(*conditional)
+			if !ok {
+				return nil, fmt.Errorf("%s: output %s: unsupported connections", def.Position(), def.Module)
+			}
+			c.ifTrue = outputAt(def.Connections[0])
+			c.ifFalse = outputAt(def.Connections[1])
+			c.SetNext(outputAt(def.Connections[2]))
+		default:
+			return nil, fmt.Errorf("%s: output %s: unsupported connections", def.Position(), def.Module)
+		}
+	}
+
+	return outputs[0], nil
+}
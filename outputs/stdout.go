@@ -1,7 +1,7 @@
 package outputs
 
 import (
-	"fmt"
+	"os"
 
 	"github.com/lfkeitel/spartan/codecs"
 	"github.com/lfkeitel/spartan/event"
@@ -31,17 +31,11 @@ func newStdOutOutput(options map[string]interface{}) (Output, error) {
 }
 
 func (o *StdOutOutput) setConfig(options map[string]interface{}) error {
-	if s, exists := options["codec"]; exists {
-		c, err := codecs.New(s.(string))
-		if err != nil {
-			return err
-		}
-		o.config.codec = c
-	} else {
-		c, _ := codecs.New("json")
-		o.config.codec = c
+	codec, err := codecOption(options)
+	if err != nil {
+		return err
 	}
-
+	o.config.codec = codec
 	return nil
 }
 
@@ -53,9 +47,10 @@ func (o *StdOutOutput) SetNext(next Output) {
 // Run processes a batch.
 func (o *StdOutOutput) Run(batch []*event.Event) {
 	for _, event := range batch {
-		if event != nil {
-			fmt.Printf("%s\n", o.config.codec.Encode(event))
+		if event == nil {
+			continue
 		}
+		os.Stdout.Write(encodeLine(o.config.codec, event))
 	}
 	o.next.Run(batch)
 }
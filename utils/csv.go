@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrCSVQuote is returned by SplitCSV when a quoted column isn't closed or is
+// followed by text other than a separator.
+var ErrCSVQuote = errors.New("Invalid quoting in CSV line")
+
+// SplitCSV splits a single CSV line into columns separated by sep. Columns may be
+// quoted with quote, a quote inside a quoted column is written twice.
+func SplitCSV(line string, sep, quote rune) ([]string, error) {
+	line = strings.TrimRight(line, "\r\n")
+	var columns []string
+
+	for {
+		if !strings.HasPrefix(line, string(quote)) {
+			i := strings.IndexRune(line, sep)
+			if i < 0 {
+				return append(columns, line), nil
+			}
+			columns = append(columns, line[:i])
+			line = line[i+utf8.RuneLen(sep):]
+			continue
+		}
+
+		// Quoted column
+		line = line[utf8.RuneLen(quote):]
+		var column []byte
+		for {
+			i := strings.IndexRune(line, quote)
+			if i < 0 {
+				return nil, ErrCSVQuote
+			}
+			column = append(column, line[:i]...)
+			line = line[i+utf8.RuneLen(quote):]
+
+			if strings.HasPrefix(line, string(quote)) {
+				column = append(column, string(quote)...)
+				line = line[utf8.RuneLen(quote):]
+				continue
+			}
+			break
+		}
+		columns = append(columns, string(column))
+
+		if line == "" {
+			return columns, nil
+		}
+		if !strings.HasPrefix(line, string(sep)) {
+			return nil, ErrCSVQuote
+		}
+		line = line[utf8.RuneLen(sep):]
+	}
+}
+
+// JoinCSV joins columns into a CSV line separated by sep. Columns containing sep,
+// quote, or a line break are quoted with quote.
+func JoinCSV(columns []string, sep, quote rune) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		if !strings.ContainsRune(column, sep) && !strings.ContainsRune(column, quote) &&
+			!strings.ContainsAny(column, "\r\n") {
+			quoted[i] = column
+			continue
+		}
+		q := string(quote)
+		quoted[i] = q + strings.Replace(column, q, q+q, -1) + q
+	}
+	return strings.Join(quoted, string(sep))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCSV(t *testing.T) {
+	tests := []struct {
+		line     string
+		sep      rune
+		expected []string
+	}{
+		{"a,b,c", ',', []string{"a", "b", "c"}},
+		{"a,,c,\r\n", ',', []string{"a", "", "c", ""}},
+		{`"a,b","say ""hi""",c`, ',', []string{"a,b", `say "hi"`, "c"}},
+		{`""`, ',', []string{""}},
+		{"a\tb c", '\t', []string{"a", "b c"}},
+		{"é;ü", ';', []string{"é", "ü"}},
+	}
+
+	for _, test := range tests {
+		columns, err := SplitCSV(test.line, test.sep, '"')
+		if err != nil {
+			t.Errorf("%q: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(columns, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.line, test.expected, columns)
+		}
+	}
+
+	for _, line := range []string{`"a,b`, `"a"b,c`} {
+		if _, err := SplitCSV(line, ',', '"'); err != ErrCSVQuote {
+			t.Errorf("%q: expected ErrCSVQuote, got %v", line, err)
+		}
+	}
+}
+
+func TestJoinCSV(t *testing.T) {
+	columns := []string{"a", "b,c", `say "hi"`, "two\nlines", ""}
+	line := JoinCSV(columns, ',', '"')
+	if expected := "a,\"b,c\",\"say \"\"hi\"\"\",\"two\nlines\","; line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+
+	split, err := SplitCSV(JoinCSV(columns[:3], '|', '\''), '|', '\'')
+	if err != nil || !reflect.DeepEqual(split, columns[:3]) {
+		t.Errorf("Expected %q, got %q (%v)", columns[:3], split, err)
+	}
+}
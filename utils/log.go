@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A LogLevel is the severity of a log message.
+type LogLevel int
+
+// The available log levels from most to least verbose.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarning
+	LogLevelError
+)
+
+var logLevelNames = [...]string{
+	LogLevelDebug:   "debug",
+	LogLevelInfo:    "info",
+	LogLevelWarning: "warning",
+	LogLevelError:   "error",
+}
+
+// String returns the lowercase name of the level.
+func (l LogLevel) String() string {
+	if l < LogLevelDebug || l > LogLevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel returns the LogLevel named s. Names are case insensitive.
+func ParseLogLevel(s string) (LogLevel, error) {
+	s = strings.ToLower(s)
+	for level, name := range logLevelNames {
+		if name == s {
+			return LogLevel(level), nil
+		}
+	}
+	return LogLevelInfo, fmt.Errorf("Invalid log level %s", s)
+}
+
+// A Logger writes leveled log messages. Messages below the Logger's
+// level are discarded.
+type Logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level LogLevel
+}
+
+// Log is the application wide Logger.
+var Log = NewLogger(os.Stderr, LogLevelInfo)
+
+// NewLogger creates a Logger writing to out.
+func NewLogger(out io.Writer, level LogLevel) *Logger {
+	return &Logger{
+		out:   out,
+		level: level,
+	}
+}
+
+// SetLevel sets the minimum level of messages that will be written.
+func (l *Logger) SetLevel(level LogLevel) {
+	l.mu.Lock()
+	l.level = level
+	l.mu.Unlock()
+}
+
+// Debugf logs a formatted message at the debug level.
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.logf(LogLevelDebug, format, v...)
+}
+
+// Infof logs a formatted message at the info level.
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.logf(LogLevelInfo, format, v...)
+}
+
+// Warningf logs a formatted message at the warning level.
+func (l *Logger) Warningf(format string, v ...interface{}) {
+	l.logf(LogLevelWarning, format, v...)
+}
+
+// Errorf logs a formatted message at the error level.
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.logf(LogLevelError, format, v...)
+}
+
+func (l *Logger) logf(level LogLevel, format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level < l.level {
+		return
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	fmt.Fprintf(l.out, "%s %-7s %s\n",
+		time.Now().Format(time.RFC3339), strings.ToUpper(level.String()), strings.TrimSuffix(msg, "\n"))
+}
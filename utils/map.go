@@ -1,6 +1,10 @@
 package utils
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
 
 // An InterfaceMap is a wrapper object around map[string]interface{}.
 // It's purpose is to more easily manipulate interface{} values in particular
@@ -23,31 +27,155 @@ func NewMap(data map[string]interface{}) *InterfaceMap {
 	}
 }
 
-// Set the key to val.
+// Map returns the underlying map of m. Changes made to the returned
+// map will be reflected in m.
+func (m *InterfaceMap) Map() map[string]interface{} {
+	return m.d
+}
+
+// ParseFieldPath splits a field path such as [a][b][c] into its keys. A key
+// that isn't a bracketed path, such as "a" or "a.b", is returned as is.
+func ParseFieldPath(key string) []string {
+	if !isFieldPath(key) {
+		return []string{key}
+	}
+
+	path := strings.Split(key[1:len(key)-1], "][")
+	for _, k := range path {
+		if k == "" || strings.ContainsAny(k, "[]") {
+			return []string{key}
+		}
+	}
+	return path
+}
+
+func isFieldPath(key string) bool {
+	return len(key) > 2 && key[0] == '[' && key[len(key)-1] == ']'
+}
+
+// Set the key to val. key may be a path such as [a][b] to set a value in
+// nested maps. Missing maps along the path are created and values that aren't
+// maps are replaced.
 func (m *InterfaceMap) Set(key string, val interface{}) {
-	m.d[key] = val
+	if !isFieldPath(key) {
+		m.d[key] = val
+		return
+	}
+	setPath(m, ParseFieldPath(key), val)
 }
 
-// Get the value of key.
+// Get the value of key. key may be a path such as [a][b] to get a value in
+// nested maps.
 func (m *InterfaceMap) Get(key string) interface{} {
-	return m.d[key]
+	v, _ := m.GetOK(key)
+	return v
 }
 
 // GetOK gets the value of key and if it exists.
 func (m *InterfaceMap) GetOK(key string) (interface{}, bool) {
-	v, ok := m.d[key]
-	return v, ok
+	if !isFieldPath(key) {
+		v, ok := m.d[key]
+		return v, ok
+	}
+
+	var v interface{} = m
+	for _, k := range ParseFieldPath(key) {
+		var ok bool
+		if v, ok = mapValue(v, k); !ok {
+			return nil, false
+		}
+	}
+	return v, true
 }
 
 // KeyExists returns if the key exists in the map.
 func (m *InterfaceMap) KeyExists(key string) bool {
-	_, ok := m.d[key]
+	_, ok := m.GetOK(key)
 	return ok
 }
 
 // Delete key from the map.
 func (m *InterfaceMap) Delete(key string) {
-	delete(m.d, key)
+	if !isFieldPath(key) {
+		delete(m.d, key)
+		return
+	}
+
+	path := ParseFieldPath(key)
+	var parent interface{} = m
+	for _, k := range path[:len(path)-1] {
+		var ok bool
+		if parent, ok = mapValue(parent, k); !ok {
+			return
+		}
+	}
+
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case *InterfaceMap:
+		delete(p.d, last)
+	case map[string]interface{}:
+		delete(p, last)
+	case map[string]string:
+		delete(p, last)
+	}
+}
+
+// mapValue returns the value of key in container if container is a map.
+func mapValue(container interface{}, key string) (interface{}, bool) {
+	switch c := container.(type) {
+	case *InterfaceMap:
+		v, ok := c.d[key]
+		return v, ok
+	case map[string]interface{}:
+		v, ok := c[key]
+		return v, ok
+	case map[string]string:
+		v, ok := c[key]
+		return v, ok
+	}
+	return nil, false
+}
+
+// setPath sets the value at path in container and returns the container. If
+// container can't hold the value a new map is returned in its place.
+func setPath(container interface{}, path []string, val interface{}) interface{} {
+	key := path[0]
+	if len(path) > 1 {
+		child, _ := mapValue(container, key)
+		val = setPath(child, path[1:], val)
+	}
+
+	switch c := container.(type) {
+	case *InterfaceMap:
+		c.d[key] = val
+		return c
+	case map[string]interface{}:
+		c[key] = val
+		return c
+	case map[string]string:
+		if s, ok := val.(string); ok {
+			c[key] = s
+			return c
+		}
+		n := make(map[string]interface{}, len(c)+1)
+		for k, v := range c {
+			n[k] = v
+		}
+		n[key] = val
+		return n
+	}
+	return map[string]interface{}{key: val}
+}
+
+// Keys returns the keys of the map in sorted order.
+func (m *InterfaceMap) Keys() []string {
+	keys := make([]string, 0, len(m.d))
+	for k := range m.d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // Len returns the count of items in the map.
@@ -65,6 +193,46 @@ func (m *InterfaceMap) Copy() *InterfaceMap {
 	return n
 }
 
+// DeepCopy returns a copy of val where maps and arrays, including those nested
+// inside them, are new values. Other values are returned as is.
+func DeepCopy(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *InterfaceMap:
+		n := NewInterfaceMap()
+		for k, v := range v.d {
+			n.d[k] = DeepCopy(v)
+		}
+		return n
+	case map[string]interface{}:
+		n := make(map[string]interface{}, len(v))
+		for k, v := range v {
+			n[k] = DeepCopy(v)
+		}
+		return n
+	case map[string]string:
+		n := make(map[string]string, len(v))
+		for k, v := range v {
+			n[k] = v
+		}
+		return n
+	case []interface{}:
+		n := make([]interface{}, len(v))
+		for i, v := range v {
+			n[i] = DeepCopy(v)
+		}
+		return n
+	case []string:
+		n := make([]string, len(v))
+		copy(n, v)
+		return n
+	case []int:
+		n := make([]int, len(v))
+		copy(n, v)
+		return n
+	}
+	return val
+}
+
 // MarshalJSON marshals the underlying map instead of the struct.
 func (m *InterfaceMap) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.d)
@@ -72,5 +240,5 @@ func (m *InterfaceMap) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON unmarshals the underlying map instead of the struct.
 func (m *InterfaceMap) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, m.d)
+	return json.Unmarshal(data, &m.d)
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := map[string][]string{
+		"a":         {"a"},
+		"a.b":       {"a.b"},
+		"[a]":       {"a"},
+		"[a][b][c]": {"a", "b", "c"},
+		"[a.b][c]":  {"a.b", "c"},
+		"[]":        {"[]"},
+		"[a][]":     {"[a][]"},
+		"[a]b":      {"[a]b"},
+		"[a][b]]":   {"[a][b]]"},
+		"":          {""},
+	}
+
+	for key, expected := range tests {
+		if path := ParseFieldPath(key); !reflect.DeepEqual(path, expected) {
+			t.Errorf("%q: expected %q, got %q", key, expected, path)
+		}
+	}
+}
+
+func TestInterfaceMapPaths(t *testing.T) {
+	m := NewInterfaceMap()
+	m.Set("[a][b][c]", 1)
+	m.Set("[a][d]", "x")
+	m.Set("headers", map[string]string{"Host": "web1"})
+	m.Set("[headers][Accept]", "*/*")
+	m.Set("[headers][Length]", 10)
+	m.Set("[scalar][b]", 2)
+	m.Set("scalar", "text")
+	m.Set("[scalar][b]", 3)
+
+	expected := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": 1},
+			"d": "x",
+		},
+		"headers": map[string]interface{}{"Host": "web1", "Accept": "*/*", "Length": 10},
+		"scalar":  map[string]interface{}{"b": 3},
+	}
+	if !reflect.DeepEqual(m.Map(), expected) {
+		t.Fatalf("Expected %v, got %v", expected, m.Map())
+	}
+
+	if m.Get("[a][b][c]") != 1 || m.Get("[a]") == nil || m.Get("[headers][Host]") != "web1" {
+		t.Error("Nested values not found")
+	}
+	if m.KeyExists("[a][b][x]") || m.KeyExists("[a][d][x]") || m.Get("[missing][b]") != nil {
+		t.Error("Missing nested values found")
+	}
+
+	m.Delete("[a][b][c]")
+	m.Delete("[headers][Host]")
+	m.Delete("[missing][b]")
+	if m.KeyExists("[a][b][c]") || !m.KeyExists("[a][b]") || m.KeyExists("[headers][Host]") {
+		t.Error("Nested values not deleted")
+	}
+
+	nested := NewInterfaceMap()
+	m.Set("map", nested)
+	m.Set("[map][key]", "val")
+	if nested.Get("key") != "val" {
+		t.Error("Value not set in nested InterfaceMap")
+	}
+}
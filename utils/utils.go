@@ -1,11 +1,28 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 // ErrNotImplemented is used in placeholders to indicate a function
 // or feature that isn't implemented yet.
 var ErrNotImplemented = errors.New("Feature not implemented")
 
+// ParseDelimiter returns the delimiter given in a configuration string. Configuration
+// strings are raw, escapes such as \n and \x00 are allowed.
+func ParseDelimiter(val interface{}) ([]byte, error) {
+	delim, ok := val.(string)
+	if !ok || delim == "" {
+		return nil, errors.New("delimiter must be a non-empty string")
+	}
+	delim, err := strconv.Unquote(`"` + delim + `"`)
+	if err != nil || delim == "" {
+		return nil, errors.New("delimiter has an invalid escape sequence")
+	}
+	return []byte(delim), nil
+}
+
 // StringInSlice checks haystack for the presense of needle
 func StringInSlice(needle string, haystack []string) bool {
 	for _, s := range haystack {